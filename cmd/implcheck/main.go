@@ -0,0 +1,475 @@
+// Command implcheck reports interfaces without implementations and exported types that only
+// implement interfaces nobody uses, the same checks as the extension's reportOrphanInterfaces and
+// reportDeadImplementations settings, so they can run in CI.
+//
+// Usage:
+//
+//	implcheck [-orphan-interfaces=false] [-dead-implementations=false] [dir ...]
+//
+// Every directory is checked recursively, skipping vendor, testdata and hidden directories. Issues
+// are printed as "file:line:col: message" and make the command exit with status 1.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func main() {
+	orphans := flag.Bool("orphan-interfaces", true, "report interfaces without implementations")
+	dead := flag.Bool("dead-implementations", true, "report exported types that only implement unused interfaces")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: implcheck [flags] [dir ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	roots := flag.Args()
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+
+	issues, err := check(roots, *orphans, *dead)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "implcheck: %v\n", err)
+		os.Exit(2)
+	}
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+	if len(issues) > 0 {
+		os.Exit(1)
+	}
+}
+
+type issue struct {
+	pos     token.Position
+	message string
+}
+
+func (i issue) String() string {
+	name := i.pos.Filename
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, name); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", name, i.pos.Line, i.pos.Column, i.message)
+}
+
+func check(roots []string, orphans, dead bool) ([]issue, error) {
+	var dirs []string
+	for _, root := range roots {
+		// Directories are checked recursively anyway, so "./..." means the same as "."
+		if root = strings.TrimSuffix(root, "..."); root == "" {
+			root = "."
+		}
+		found, err := findPackageDirs(root)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, found...)
+	}
+	if len(dirs) == 0 {
+		return nil, nil
+	}
+
+	l, err := newLoader(dirs[0])
+	if err != nil {
+		return nil, err
+	}
+	var checked []*loadedPackage
+	for _, dir := range dirs {
+		pkg := l.load(dir)
+		if pkg == nil {
+			continue
+		}
+		if pkg.errors > 0 {
+			fmt.Fprintf(os.Stderr, "implcheck: %s: %d type errors, results may be incomplete\n", dir, pkg.errors)
+		}
+		checked = append(checked, pkg)
+	}
+
+	a := newAnalysis(l)
+	var issues []issue
+	for _, pkg := range checked {
+		if orphans {
+			issues = append(issues, a.orphanInterfaces(pkg)...)
+		}
+		if dead {
+			issues = append(issues, a.deadImplementations(pkg)...)
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].pos.Filename != issues[j].pos.Filename {
+			return issues[i].pos.Filename < issues[j].pos.Filename
+		}
+		return issues[i].pos.Offset < issues[j].pos.Offset
+	})
+	return issues, nil
+}
+
+func findPackageDirs(root string) ([]string, error) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			name := entry.Name()
+			if path != root && (name == "vendor" || name == "testdata" || name == "node_modules" ||
+				strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".go") {
+			dir, err := filepath.Abs(filepath.Dir(path))
+			if err != nil {
+				return err
+			}
+			if len(dirs) == 0 || dirs[len(dirs)-1] != dir {
+				dirs = append(dirs, dir)
+			}
+		}
+		return nil
+	})
+	return dirs, err
+}
+
+type loadedPackage struct {
+	dir    string
+	types  *types.Package
+	info   *types.Info
+	files  []*ast.File
+	errors int
+}
+
+// loader type-checks the module's packages itself, so a type and an interface from different
+// packages share their types.Package and can be compared. Other imports come from source.
+type loader struct {
+	fset       *token.FileSet
+	modulePath string
+	moduleRoot string
+	fallback   types.Importer
+	packages   map[string]*loadedPackage
+	loading    map[string]bool
+}
+
+func newLoader(dir string) (*loader, error) {
+	root, modulePath, err := findModule(dir)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	return &loader{
+		fset:       fset,
+		modulePath: modulePath,
+		moduleRoot: root,
+		fallback:   importer.ForCompiler(fset, "source", nil),
+		packages:   map[string]*loadedPackage{},
+		loading:    map[string]bool{},
+	}, nil
+}
+
+func findModule(dir string) (root, modulePath string, err error) {
+	for current := dir; ; current = filepath.Dir(current) {
+		file, err := os.Open(filepath.Join(current, "go.mod"))
+		if err == nil {
+			defer file.Close()
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				if fields := strings.Fields(scanner.Text()); len(fields) == 2 && fields[0] == "module" {
+					return current, strings.Trim(fields[1], `"`), nil
+				}
+			}
+			return "", "", fmt.Errorf("%s has no module directive", filepath.Join(current, "go.mod"))
+		}
+		if filepath.Dir(current) == current {
+			return "", "", fmt.Errorf("no go.mod found above %s", dir)
+		}
+	}
+}
+
+func (l *loader) Import(path string) (*types.Package, error) {
+	if path == l.modulePath || strings.HasPrefix(path, l.modulePath+"/") {
+		dir := filepath.Join(l.moduleRoot, filepath.FromSlash(strings.TrimPrefix(path, l.modulePath)))
+		if pkg := l.load(dir); pkg != nil {
+			return pkg.types, nil
+		}
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+	return l.fallback.Import(path)
+}
+
+func (l *loader) importPath(dir string) string {
+	rel, err := filepath.Rel(l.moduleRoot, dir)
+	if err != nil || rel == "." {
+		return l.modulePath
+	}
+	return l.modulePath + "/" + filepath.ToSlash(rel)
+}
+
+// load parses and type-checks the package in dir, including its in-package tests, since mocks
+// declared there are implementations too. External _test packages are left out.
+func (l *loader) load(dir string) *loadedPackage {
+	if pkg, ok := l.packages[dir]; ok || l.loading[dir] {
+		return pkg
+	}
+	l.loading[dir] = true
+	defer delete(l.loading, dir)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []*ast.File
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		if match, err := build.Default.MatchFile(dir, name); err != nil || !match {
+			continue
+		}
+		file, err := parser.ParseFile(l.fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil || strings.HasSuffix(file.Name.Name, "_test") {
+			continue
+		}
+		if len(files) > 0 && file.Name.Name != files[0].Name.Name {
+			continue
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		l.packages[dir] = nil
+		return nil
+	}
+
+	pkg := &loadedPackage{
+		dir:   dir,
+		files: files,
+		info: &types.Info{
+			Defs: map[*ast.Ident]types.Object{},
+			Uses: map[*ast.Ident]types.Object{},
+		},
+	}
+	config := types.Config{
+		Importer: l,
+		// Keep going after errors, the rest of the package is still worth checking
+		Error: func(error) { pkg.errors++ },
+	}
+	pkg.types, _ = config.Check(l.importPath(dir), l.fset, files, pkg.info)
+	l.packages[dir] = pkg
+	return pkg
+}
+
+type analysis struct {
+	l *loader
+	// Named types and interfaces declared in the module's packages
+	types      []*types.TypeName
+	interfaces []*types.TypeName
+	// Interfaces of imported packages outside the module, which are used implicitly
+	external []*types.Interface
+	uses     map[types.Object]int
+}
+
+func newAnalysis(l *loader) *analysis {
+	a := &analysis{l: l, uses: map[types.Object]int{}}
+	seen := map[*types.Package]bool{}
+	for _, pkg := range l.packages {
+		if pkg == nil {
+			continue
+		}
+		for _, name := range declaredTypes(pkg) {
+			if isMethodSet(name.Type()) {
+				a.interfaces = append(a.interfaces, name)
+			} else if !types.IsInterface(name.Type()) {
+				a.types = append(a.types, name)
+			}
+		}
+		for _, imported := range pkg.types.Imports() {
+			a.collectExternal(imported, seen)
+		}
+		a.countUses(pkg)
+	}
+	a.external = append(a.external, types.Universe.Lookup("error").Type().Underlying().(*types.Interface))
+	return a
+}
+
+func (a *analysis) collectExternal(pkg *types.Package, seen map[*types.Package]bool) {
+	if seen[pkg] || pkg.Path() == a.l.modulePath || strings.HasPrefix(pkg.Path(), a.l.modulePath+"/") {
+		return
+	}
+	seen[pkg] = true
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		if typeName, ok := scope.Lookup(name).(*types.TypeName); ok && typeName.Exported() && isMethodSet(typeName.Type()) {
+			a.external = append(a.external, typeName.Type().Underlying().(*types.Interface))
+		}
+	}
+}
+
+// declaredTypes lists the package-level, non-generic type declarations of the package.
+func declaredTypes(pkg *loadedPackage) []*types.TypeName {
+	var names []*types.TypeName
+	for _, file := range pkg.files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				if typeSpec.TypeParams != nil || typeSpec.Assign.IsValid() {
+					continue
+				}
+				if name, ok := pkg.info.Defs[typeSpec.Name].(*types.TypeName); ok {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// isMethodSet is true for interfaces with methods. Constraints such as "~int | ~float64" are
+// satisfied by type arguments, and everything implements an empty interface.
+func isMethodSet(t types.Type) bool {
+	iface, ok := t.Underlying().(*types.Interface)
+	return ok && iface.IsMethodSet() && iface.NumMethods() > 0
+}
+
+// countUses counts references to declared objects. Method receivers, compile-time assertions
+// such as "var _ Reader = (*File)(nil)" and references from inside a type's own declaration
+// don't count.
+func (a *analysis) countUses(pkg *loadedPackage) {
+	ignored := map[*ast.Ident]bool{}
+	ignore := func(node ast.Node) {
+		ast.Inspect(node, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok {
+				ignored[ident] = true
+			}
+			return true
+		})
+	}
+	for _, file := range pkg.files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.FuncDecl:
+				if node.Recv != nil {
+					ignore(node.Recv)
+				}
+			case *ast.ValueSpec:
+				if isAssertion(node) {
+					ignore(node)
+				}
+			case *ast.TypeSpec:
+				if def := pkg.info.Defs[node.Name]; def != nil {
+					ast.Inspect(node.Type, func(n ast.Node) bool {
+						if ident, ok := n.(*ast.Ident); ok && pkg.info.Uses[ident] == def {
+							ignored[ident] = true
+						}
+						return true
+					})
+				}
+			}
+			return true
+		})
+	}
+	for ident, object := range pkg.info.Uses {
+		if !ignored[ident] {
+			a.uses[object]++
+		}
+	}
+}
+
+func isAssertion(spec *ast.ValueSpec) bool {
+	for _, name := range spec.Names {
+		if name.Name != "_" {
+			return false
+		}
+	}
+	return spec.Type != nil && len(spec.Values) > 0
+}
+
+func implements(t types.Type, iface *types.Interface) bool {
+	return types.Implements(t, iface) || types.Implements(types.NewPointer(t), iface)
+}
+
+func (a *analysis) orphanInterfaces(pkg *loadedPackage) []issue {
+	var issues []issue
+	for _, name := range declaredTypes(pkg) {
+		if !isMethodSet(name.Type()) {
+			continue
+		}
+		iface := name.Type().Underlying().(*types.Interface)
+		implemented := false
+		for _, candidate := range a.types {
+			if implements(candidate.Type(), iface) {
+				implemented = true
+				break
+			}
+		}
+		if !implemented {
+			issues = append(issues, issue{
+				pos:     a.l.fset.Position(name.Pos()),
+				message: fmt.Sprintf("Interface %s has no implementations", name.Name()),
+			})
+		}
+	}
+	return issues
+}
+
+func (a *analysis) deadImplementations(pkg *loadedPackage) []issue {
+	var issues []issue
+	for _, name := range declaredTypes(pkg) {
+		if !name.Exported() || types.IsInterface(name.Type()) || a.uses[name] > 0 {
+			continue
+		}
+
+		var implemented []string
+		alive := false
+		for _, external := range a.external {
+			if implements(name.Type(), external) {
+				alive = true
+				break
+			}
+		}
+		for _, iface := range a.interfaces {
+			if alive {
+				break
+			}
+			if implements(name.Type(), iface.Type().Underlying().(*types.Interface)) {
+				implemented = append(implemented, iface.Name())
+				alive = a.uses[iface] > 0
+			}
+		}
+		if alive || len(implemented) == 0 {
+			continue
+		}
+
+		verb := "is"
+		if len(implemented) > 1 {
+			verb = "are"
+		}
+		issues = append(issues, issue{
+			pos: a.l.fset.Position(name.Pos()),
+			message: fmt.Sprintf("Type %s is never used and only implements %s, which %s never used",
+				name.Name(), strings.Join(implemented, ", "), verb),
+		})
+	}
+	return issues
+}
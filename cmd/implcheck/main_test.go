@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckReportsOrphanInterfacesAndDeadImplementations(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/store\n\ngo 1.21\n",
+		"store.go": `package store
+
+import "fmt"
+
+type Orphan interface {
+	Unimplemented() error
+}
+
+type Loader interface {
+	Load(key string) (string, error)
+}
+
+// Only named in its receiver and an assertion
+type DeadStore struct{}
+
+var _ Loader = (*DeadStore)(nil)
+
+func (s *DeadStore) Load(key string) (string, error) { return "", nil }
+
+// Kept alive by fmt.Stringer
+type Name struct{}
+
+func (Name) String() string { return "" }
+
+func (Name) Load(key string) (string, error) { return "", nil }
+
+var _ fmt.Stringer = Name{}
+`,
+		"used/used.go": `package used
+
+import "example.com/store"
+
+type Saver interface {
+	Save() error
+}
+
+// Used below through Saver, so neither is reported
+type LiveStore struct{}
+
+func (LiveStore) Save() error { return nil }
+
+func Save(s Saver) error { return s.Save() }
+
+func Default() Saver { return LiveStore{} }
+
+var _ store.Loader = nil
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	issues, err := check([]string{dir}, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var messages []string
+	for _, issue := range issues {
+		messages = append(messages, issue.message)
+	}
+	want := []string{
+		"Interface Orphan has no implementations",
+		"Type DeadStore is never used and only implements Loader, which is never used",
+	}
+	if strings.Join(messages, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got issues\n%s\nwant\n%s", strings.Join(messages, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -1,29 +1,46 @@
 import * as vscode from 'vscode';
 import { GoInterfaceCodeLensProvider } from './codeLensProvider';
-import { GoInterfaceGutterProvider } from './gutterDecorationProvider';
+import { GoInterfaceGutterProvider, GutterLineTarget } from './gutterDecorationProvider';
 import { GoAnalyzer } from './goAnalyzer';
-import { GoReferenceSidebarProvider } from './sidebarProvider';
+import { GoInterfaceInlayHintsProvider } from './inlayHintsProvider';
+import { getReferenceKindLabel, GoReferenceSidebarProvider } from './sidebarProvider';
+import { isSingular } from './l10n';
+import { EXPORT_FILE_EXTENSIONS, ExportFormat, formatReferences } from './referenceExporter';
+import { RefreshScheduler } from './refreshScheduler';
+import { waitForGopls } from './goplsReadiness';
+import { ImplementationStatusBar } from './statusBar';
+import { disposeLog, getLog } from './logger';
+import { InterfaceHealthProvider } from './interfaceHealth';
+import { analyzeSegregation } from './interfaceSegregation';
+import { SegregationReportPanel } from './segregationReportPanel';
+import { generateMockLensContributor, hasEmbeddedInterfaces, MockGenerator, parseMethodSignature } from './mockGenerator';
+import { getShownImplementations } from './implementationCategory';
+import { buildInterfaceMethodRename, validateMethodName } from './interfaceMethodRename';
+import { findInterfaceMethodAt, findMethodTargets, InterfaceMethodTarget, MethodTargets, refreshInterfaceMethod } from './interfaceMethodTargets';
+import { buildSignatureChange, getDefaultArgument, ParameterPosition, parseNewParameter, validateNewParameter } from './interfaceMethodSignature';
 
 export function activate(context: vscode.ExtensionContext) {
-    console.log('Go Implementation Lens - extension is now active!');
+    context.subscriptions.push({ dispose: disposeLog });
+    getLog().info(`Go Implementation Lens ${context.extension.packageJSON.version} activated`);
 
     const goAnalyzer = new GoAnalyzer();
     const codeLensProvider = new GoInterfaceCodeLensProvider(goAnalyzer);
     const gutterProvider = new GoInterfaceGutterProvider(goAnalyzer, context);
     const sidebarProvider = new GoReferenceSidebarProvider(goAnalyzer);
-    
-    // Wait a bit for gopls to initialize
-    setTimeout(() => {
-        if (vscode.window.activeTextEditor) {
-            gutterProvider.updateDecorations(vscode.window.activeTextEditor);
-        }
-    }, 2000);
+    // Inlay hints render the same lenses, so they share the CodeLens registry
+    const inlayHintsProvider = new GoInterfaceInlayHintsProvider(goAnalyzer, codeLensProvider.registry);
+    const statusBar = new ImplementationStatusBar(goAnalyzer);
     
     const codeLensProviderDisposable = vscode.languages.registerCodeLensProvider(
         { language: 'go', scheme: 'file' },
         codeLensProvider
     );
 
+    const inlayHintsProviderDisposable = vscode.languages.registerInlayHintsProvider(
+        { language: 'go', scheme: 'file' },
+        inlayHintsProvider
+    );
+
     // Register the sidebar view
     const sidebarView = vscode.window.createTreeView('goReferencesView', {
         treeDataProvider: sidebarProvider,
@@ -37,6 +54,7 @@ export function activate(context: vscode.ExtensionContext) {
     
     // Update decorations when active editor changes
     const activeEditorChangeDisposable = vscode.window.onDidChangeActiveTextEditor((editor) => {
+        gutterProvider.updateLineContext(editor);
         if (editor) {
             gutterProvider.updateDecorations(editor);
         }
@@ -50,8 +68,9 @@ export function activate(context: vscode.ExtensionContext) {
             const currentValue = config.get<boolean>('enable', true);
             config.update('enable', !currentValue, vscode.ConfigurationTarget.Global);
             
-            const status = !currentValue ? 'enabled' : 'disabled';
-            vscode.window.showInformationMessage(`Implementation CodeLens ${status}`);
+            vscode.window.showInformationMessage(!currentValue
+                ? vscode.l10n.t('Implementation CodeLens enabled')
+                : vscode.l10n.t('Implementation CodeLens disabled'));
         }
     );
 
@@ -63,8 +82,9 @@ export function activate(context: vscode.ExtensionContext) {
             const currentValue = config.get<boolean>('showGutterIcons', true);
             config.update('showGutterIcons', !currentValue, vscode.ConfigurationTarget.Global);
             
-            const status = !currentValue ? 'enabled' : 'disabled';
-            vscode.window.showInformationMessage(`Implementation Gutter Icons ${status}`);
+            vscode.window.showInformationMessage(!currentValue
+                ? vscode.l10n.t('Implementation Gutter Icons enabled')
+                : vscode.l10n.t('Implementation Gutter Icons disabled'));
             
             // Refresh gutter decorations immediately
             vscode.window.visibleTextEditors.forEach(editor => {
@@ -78,30 +98,17 @@ export function activate(context: vscode.ExtensionContext) {
     // Command for showing implementations using our analyzed data
     const showImplementationsCommand = vscode.commands.registerCommand(
         'goImplementationLens.showImplementations',
-        async (implementations: vscode.Location[], symbolName?: string) => {
+        async (implementations: vscode.Location[], symbolName?: string, anchor?: vscode.Location) => {
             try {
-                const config = vscode.workspace.getConfiguration('goImplementationLens');
-                const useSidebar = config.get<boolean>('useSidebar', true);
-                
-                if (implementations && implementations.length > 0) {
-                    if (implementations.length === 1) {
-                        // Single implementation - navigate directly
-                        await goToLocation(implementations[0]);
-                    } else if (useSidebar) {
-                        // Multiple implementations - update sidebar and show it
-                        sidebarProvider.updateReferences(symbolName || 'Symbol', implementations, []);
-                        await vscode.commands.executeCommand('workbench.view.extension.goReferences');
-                    } else {
-                        // Show custom quick pick for implementations
-                        await showQuickPickForLocations(implementations, 'Go to Implementation');
-                    }
-                } else if (useSidebar) {
-                    // No implementations - still update sidebar to show empty state
-                    sidebarProvider.updateReferences(symbolName || 'Symbol', [], []);
-                    await vscode.commands.executeCommand('workbench.view.extension.goReferences');
-                }
+                await showLocations(sidebarProvider, {
+                    implementations: implementations || [],
+                    references: [],
+                    symbolName: symbolName,
+                    quickPickTitle: vscode.l10n.t('Go to Implementation'),
+                    anchor: anchor
+                });
             } catch (error) {
-                vscode.window.showErrorMessage(`Error showing implementations: ${error}`);
+                vscode.window.showErrorMessage(vscode.l10n.t('Error showing implementations: {0}', String(error)));
             }
         }
     );
@@ -109,33 +116,19 @@ export function activate(context: vscode.ExtensionContext) {
     // Command for showing both implementations and references
     const showImplementationsAndReferencesCommand = vscode.commands.registerCommand(
         'goImplementationLens.showImplementationsAndReferences',
-        async (implementations: vscode.Location[], references: vscode.Location[], symbolName?: string) => {
+        async (implementations: vscode.Location[], references: vscode.Location[], symbolName?: string, symbolKind?: vscode.SymbolKind, anchor?: vscode.Location) => {
             try {
-                const config = vscode.workspace.getConfiguration('goImplementationLens');
-                const useSidebar = config.get<boolean>('useSidebar', true);
-                const allLocations = [...(implementations || []), ...(references || [])];
-                
-                if (allLocations.length === 1) {
-                    // Single item total - navigate directly
-                    await goToLocation(allLocations[0]);
-                } else if (allLocations.length > 1 && useSidebar) {
-                    // Multiple items - update sidebar and show it
-                    sidebarProvider.updateReferences(symbolName || 'Symbol', implementations || [], references || []);
-                    await vscode.commands.executeCommand('workbench.view.extension.goReferences');
-                } else if (allLocations.length > 1) {
-                    // Show custom quick pick - prefer implementations if available
-                    if (implementations && implementations.length > 0) {
-                        await showQuickPickForLocations(implementations, 'Go to Implementation');
-                    } else if (references && references.length > 0) {
-                        await showQuickPickForLocations(references, 'Go to References');
-                    }
-                } else if (useSidebar) {
-                    // No items - still update sidebar to show empty state
-                    sidebarProvider.updateReferences(symbolName || 'Symbol', [], []);
-                    await vscode.commands.executeCommand('workbench.view.extension.goReferences');
-                }
+                await showLocations(sidebarProvider, {
+                    implementations: implementations || [],
+                    references: references || [],
+                    symbolName: symbolName,
+                    symbolKind: symbolKind,
+                    // The quick pick prefers implementations if available
+                    quickPickTitle: implementations && implementations.length > 0 ? vscode.l10n.t('Go to Implementation') : vscode.l10n.t('Go to References'),
+                    anchor: anchor
+                });
             } catch (error) {
-                vscode.window.showErrorMessage(`Error showing implementations and references: ${error}`);
+                vscode.window.showErrorMessage(vscode.l10n.t('Error showing implementations and references: {0}', String(error)));
             }
         }
     );
@@ -143,30 +136,18 @@ export function activate(context: vscode.ExtensionContext) {
     // Command for showing only references
     const showReferencesCommand = vscode.commands.registerCommand(
         'goImplementationLens.showReferences',
-        async (references: vscode.Location[], symbolName?: string) => {
+        async (references: vscode.Location[], symbolName?: string, symbolKind?: vscode.SymbolKind, anchor?: vscode.Location) => {
             try {
-                const config = vscode.workspace.getConfiguration('goImplementationLens');
-                const useSidebar = config.get<boolean>('useSidebar', true);
-                
-                if (references && references.length > 0) {
-                    if (references.length === 1) {
-                        // Single reference - navigate directly
-                        await goToLocation(references[0]);
-                    } else if (useSidebar) {
-                        // Multiple references - update sidebar and show it
-                        sidebarProvider.updateReferences(symbolName || 'Symbol', [], references);
-                        await vscode.commands.executeCommand('workbench.view.extension.goReferences');
-                    } else {
-                        // Show custom quick pick for references
-                        await showQuickPickForLocations(references, 'Go to References');
-                    }
-                } else if (useSidebar) {
-                    // No references - still update sidebar to show empty state
-                    sidebarProvider.updateReferences(symbolName || 'Symbol', [], []);
-                    await vscode.commands.executeCommand('workbench.view.extension.goReferences');
-                }
+                await showLocations(sidebarProvider, {
+                    implementations: [],
+                    references: references || [],
+                    symbolName: symbolName,
+                    symbolKind: symbolKind,
+                    quickPickTitle: vscode.l10n.t('Go to References'),
+                    anchor: anchor
+                });
             } catch (error) {
-                vscode.window.showErrorMessage(`Error showing references: ${error}`);
+                vscode.window.showErrorMessage(vscode.l10n.t('Error showing references: {0}', String(error)));
             }
         }
     );
@@ -183,7 +164,7 @@ export function activate(context: vscode.ExtensionContext) {
                 editor.selection = new vscode.Selection(vscodePosition, vscodePosition);
                 editor.revealRange(new vscode.Range(vscodePosition, vscodePosition), vscode.TextEditorRevealType.InCenter);
             } catch (error) {
-                vscode.window.showErrorMessage(`Error navigating to interface: ${error}`);
+                vscode.window.showErrorMessage(vscode.l10n.t('Error navigating to interface: {0}', String(error)));
             }
         }
     );
@@ -191,54 +172,410 @@ export function activate(context: vscode.ExtensionContext) {
     // Command for navigating to interface definitions from struct CodeLens
     const goToInterfaceDefinitionsCommand = vscode.commands.registerCommand(
         'goImplementationLens.goToInterfaceDefinitions',
-        async (interfaceLocations: vscode.Location[]) => {
+        async (interfaceLocations: vscode.Location[], typeName?: string, anchor?: vscode.Location) => {
             try {
-                if (interfaceLocations && interfaceLocations.length > 0) {
-                    if (interfaceLocations.length === 1) {
-                        // Single interface - navigate directly
-                        const location = interfaceLocations[0];
-                        await goToLocation(location);
-                    } else {
-                        // Multiple interfaces - show quick pick
-                        const items = await Promise.all(interfaceLocations.map(async (location, index) => {
-                            const doc = await vscode.workspace.openTextDocument(location.uri);
-                            const line = doc.lineAt(location.range.start.line);
-                            return {
-                                label: `${index + 1}. ${vscode.workspace.asRelativePath(location.uri)}:${location.range.start.line + 1}`,
-                                description: line.text.trim(),
-                                location: location
-                            };
-                        }));
-                        
-                        const selected = await vscode.window.showQuickPick(items, {
-                            placeHolder: 'Select an interface'
-                        });
-                        
-                        if (selected) {
-                            await goToLocation(selected.location);
-                        }
+                if (!interfaceLocations || interfaceLocations.length === 0) {
+                    return;
+                }
+                
+                await showLocations(sidebarProvider, {
+                    implementations: interfaceLocations,
+                    references: [],
+                    symbolName: typeName,
+                    title: typeName ? vscode.l10n.t('Interfaces implemented by {0}', typeName) : vscode.l10n.t('Implemented Interfaces'),
+                    quickPickTitle: vscode.l10n.t('Go to Interface'),
+                    anchor: anchor
+                });
+            } catch (error) {
+                vscode.window.showErrorMessage(vscode.l10n.t('Error navigating to interface definitions: {0}', String(error)));
+            }
+        }
+    );
+
+    // Keyboard-driven navigation: resolve the symbol under the cursor instead of taking CodeLens arguments
+    const goToImplementationsAtCursorCommand = vscode.commands.registerTextEditorCommand(
+        'goImplementationLens.goToImplementationsAtCursor',
+        async (editor) => {
+            try {
+                const position = editor.selection.active;
+                const { interfaceInfo, interfaceMethod } = await goAnalyzer.findSymbolsAt(editor.document, position);
+                if (!interfaceInfo) {
+                    vscode.window.showInformationMessage(vscode.l10n.t('No interface or interface method at the cursor'));
+                    return;
+                }
+                
+                await showLocations(sidebarProvider, {
+                    implementations: getShownImplementations(interfaceMethod || interfaceInfo, vscode.workspace.getConfiguration('goImplementationLens')),
+                    references: [],
+                    symbolName: interfaceMethod ? `${interfaceInfo.name}.${interfaceMethod.name}` : interfaceInfo.name,
+                    quickPickTitle: vscode.l10n.t('Go to Implementation'),
+                    anchor: new vscode.Location(editor.document.uri, position)
+                });
+            } catch (error) {
+                vscode.window.showErrorMessage(vscode.l10n.t('Error showing implementations: {0}', String(error)));
+            }
+        }
+    );
+
+    const analyzeInterfaceSegregationCommand = vscode.commands.registerTextEditorCommand(
+        'goImplementationLens.analyzeInterfaceSegregation',
+        async (editor) => {
+            try {
+                const { interfaceInfo } = await goAnalyzer.findSymbolsAt(editor.document, editor.selection.active);
+                if (!interfaceInfo) {
+                    vscode.window.showInformationMessage(vscode.l10n.t('No interface with implementations at the cursor'));
+                    return;
+                }
+
+                // Every reference is resolved to its definition, which takes a while for widely used interfaces
+                const report = await vscode.window.withProgress({
+                    location: vscode.ProgressLocation.Notification,
+                    title: vscode.l10n.t('Analyzing how {0} is used…', interfaceInfo.name),
+                    cancellable: true
+                }, (_progress, token) => analyzeSegregation(goAnalyzer, editor.document, interfaceInfo, token));
+                if (report) {
+                    await SegregationReportPanel.show(report);
+                }
+            } catch (error) {
+                vscode.window.showErrorMessage(vscode.l10n.t('Error analyzing the interface: {0}', String(error)));
+            }
+        }
+    );
+
+    const renameInterfaceMethodCommand = vscode.commands.registerTextEditorCommand(
+        'goImplementationLens.renameInterfaceMethod',
+        async (editor) => {
+            try {
+                const target = await findInterfaceMethodAt(goAnalyzer, editor.document, editor.selection.active);
+                if (!target) {
+                    vscode.window.showInformationMessage(vscode.l10n.t('No interface method at the cursor'));
+                    return;
+                }
+
+                const { interfaceInfo, method } = target;
+                const newName = await vscode.window.showInputBox({
+                    title: vscode.l10n.t('Rename {0}.{1} Everywhere', interfaceInfo.name, method.name),
+                    prompt: vscode.l10n.t('New name for the interface method, its implementations and call sites'),
+                    value: method.name,
+                    validateInput: value => validateMethodName(value, interfaceInfo, method)
+                });
+                if (!newName || newName === method.name) {
+                    return;
+                }
+
+                const current = await refreshInterfaceMethod(goAnalyzer, target);
+                if (!current) {
+                    vscode.window.showWarningMessage(vscode.l10n.t('{0}.{1} no longer exists', interfaceInfo.name, method.name));
+                    return;
+                }
+                const targets = await findConfirmedMethodTargets(goAnalyzer, current);
+                if (targets) {
+                    // Every change needs confirmation, so this opens the refactor preview
+                    await vscode.workspace.applyEdit(await buildInterfaceMethodRename(current.document, current.method, targets, newName));
+                }
+            } catch (error) {
+                vscode.window.showErrorMessage(vscode.l10n.t('Error renaming the interface method: {0}', error instanceof Error ? error.message : String(error)));
+            }
+        }
+    );
+
+    const changeInterfaceMethodSignatureCommand = vscode.commands.registerTextEditorCommand(
+        'goImplementationLens.changeInterfaceMethodSignature',
+        async (editor) => {
+            try {
+                const target = await findInterfaceMethodAt(goAnalyzer, editor.document, editor.selection.active);
+                if (!target) {
+                    vscode.window.showInformationMessage(vscode.l10n.t('No interface method at the cursor'));
+                    return;
+                }
+
+                const { interfaceInfo, method } = target;
+                const title = vscode.l10n.t('Add Parameter to {0}.{1}', interfaceInfo.name, method.name);
+                // Signatures may span lines, and continue after the symbol's range with a trailing comment
+                const signature = target.document.getText(new vscode.Range(method.range.start, target.document.lineAt(method.range.end.line).range.end));
+                const params = parseMethodSignature(signature)?.params || [];
+                const parameterText = await vscode.window.showInputBox({
+                    title: title,
+                    prompt: vscode.l10n.t('Parameter to add to the interface method and its implementations, e.g. "ctx context.Context"'),
+                    validateInput: value => validateNewParameter(value, params)
+                });
+                const parameter = parameterText && parseNewParameter(parameterText);
+                if (!parameter) {
+                    return;
+                }
+
+                // Nothing can follow a variadic parameter
+                let position: ParameterPosition = 'first';
+                if (params.length > 0 && !params[params.length - 1].type.startsWith('...')) {
+                    const picked = await vscode.window.showQuickPick([
+                        { label: vscode.l10n.t('First Parameter'), position: 'first' as ParameterPosition },
+                        { label: vscode.l10n.t('Last Parameter'), position: 'last' as ParameterPosition }
+                    ], { title: title, placeHolder: vscode.l10n.t('Where to add {0}', parameter.name) });
+                    if (!picked) {
+                        return;
                     }
+                    position = picked.position;
+                }
+
+                const config = vscode.workspace.getConfiguration('goImplementationLens');
+                const defaultArgument = await vscode.window.showInputBox({
+                    title: title,
+                    prompt: vscode.l10n.t('Argument to pass for {0} at the call sites. Leave empty to leave the call sites unchanged', parameter.name),
+                    value: getDefaultArgument(parameter.type, config.get<Record<string, string>>('defaultArguments', {}))
+                });
+                if (defaultArgument === undefined) {
+                    return;
+                }
+
+                const current = await refreshInterfaceMethod(goAnalyzer, target);
+                if (!current) {
+                    vscode.window.showWarningMessage(vscode.l10n.t('{0}.{1} no longer exists', interfaceInfo.name, method.name));
+                    return;
+                }
+                const targets = await findConfirmedMethodTargets(goAnalyzer, current);
+                if (!targets) {
+                    return;
+                }
+                const change = await buildSignatureChange(goAnalyzer, current.document, current.method, targets, {
+                    ...parameter,
+                    position: position,
+                    defaultArgument: defaultArgument.trim() || undefined
+                });
+                // Every change needs confirmation, so this opens the refactor preview
+                if (await vscode.workspace.applyEdit(change.edit) && change.skippedReferences.length > 0) {
+                    vscode.window.showWarningMessage(isSingular(change.skippedReferences.length)
+                        ? vscode.l10n.t('{0} reference to {1} is not a call and was left unchanged', change.skippedReferences.length, method.name)
+                        : vscode.l10n.t('{0} references to {1} are not calls and were left unchanged', change.skippedReferences.length, method.name));
+                }
+            } catch (error) {
+                vscode.window.showErrorMessage(vscode.l10n.t('Error changing the signature: {0}', error instanceof Error ? error.message : String(error)));
+            }
+        }
+    );
+
+    const goToImplementedInterfacesCommand = vscode.commands.registerTextEditorCommand(
+        'goImplementationLens.goToImplementedInterfaces',
+        async (editor) => {
+            try {
+                const position = editor.selection.active;
+                const { typeInfo } = await goAnalyzer.findSymbolsAt(editor.document, position);
+                if (!typeInfo || typeInfo.implementedInterfaces.length === 0) {
+                    vscode.window.showInformationMessage(vscode.l10n.t('No type implementing an interface at the cursor'));
+                    return;
+                }
+                
+                await showLocations(sidebarProvider, {
+                    implementations: typeInfo.implementedInterfaces,
+                    references: [],
+                    symbolName: typeInfo.name,
+                    title: vscode.l10n.t('Interfaces implemented by {0}', typeInfo.name),
+                    quickPickTitle: vscode.l10n.t('Go to Interface'),
+                    anchor: new vscode.Location(editor.document.uri, position)
+                });
+            } catch (error) {
+                vscode.window.showErrorMessage(vscode.l10n.t('Error navigating to interface definitions: {0}', String(error)));
+            }
+        }
+    );
+
+    const goToInterfaceMethodCommand = vscode.commands.registerTextEditorCommand(
+        'goImplementationLens.goToInterfaceMethod',
+        async (editor) => {
+            try {
+                const position = editor.selection.active;
+                const { methodImplementation } = await goAnalyzer.findSymbolsAt(editor.document, position);
+                if (!methodImplementation || methodImplementation.interfaceMethods.length === 0) {
+                    vscode.window.showInformationMessage(vscode.l10n.t('No method implementing an interface at the cursor'));
+                    return;
                 }
+                
+                await showLocations(sidebarProvider, {
+                    implementations: methodImplementation.interfaceMethods,
+                    references: [],
+                    symbolName: methodImplementation.name,
+                    title: vscode.l10n.t('Interface methods implemented by {0}', methodImplementation.name),
+                    quickPickTitle: vscode.l10n.t('Go to Interface Method'),
+                    anchor: new vscode.Location(editor.document.uri, position)
+                });
             } catch (error) {
-                vscode.window.showErrorMessage(`Error navigating to interface definitions: ${error}`);
+                vscode.window.showErrorMessage(vscode.l10n.t('Error navigating to interface: {0}', String(error)));
             }
         }
     );
 
+    const cycleImplementations = async (editor: vscode.TextEditor, step: number) => {
+        try {
+            const cycle = await getImplementationCycle(goAnalyzer, editor.document, editor.selection.active);
+            if (!cycle || cycle.locations.length === 0) {
+                vscode.window.showInformationMessage(vscode.l10n.t('No implementations to cycle through at the cursor'));
+                return;
+            }
+            
+            // From the interface itself, "next" starts at the first implementation and "previous" at the last
+            const count = cycle.locations.length;
+            const index = cycle.currentIndex === -1
+                ? (step > 0 ? 0 : count - 1)
+                : (cycle.currentIndex + step + count) % count;
+            await goToLocation(cycle.locations[index]);
+            vscode.window.setStatusBarMessage(vscode.l10n.t('Implementation {0} of {1}', index + 1, count), 3000);
+        } catch (error) {
+            vscode.window.showErrorMessage(vscode.l10n.t('Error cycling through implementations: {0}', String(error)));
+        }
+    };
+
+    const nextImplementationCommand = vscode.commands.registerTextEditorCommand(
+        'goImplementationLens.nextImplementation',
+        (editor) => cycleImplementations(editor, 1)
+    );
+
+    const previousImplementationCommand = vscode.commands.registerTextEditorCommand(
+        'goImplementationLens.previousImplementation',
+        (editor) => cycleImplementations(editor, -1)
+    );
+
     // Command for opening references from the sidebar
     const openReferenceCommand = vscode.commands.registerCommand(
         'goImplementationLens.openReference',
         async (location: vscode.Location) => {
             try {
-                await goToLocation(location);
+                const config = vscode.workspace.getConfiguration('goImplementationLens');
+                if (config.get<boolean>('previewInSidePane', false)) {
+                    await previewLocation(location);
+                } else {
+                    await goToLocation(location);
+                }
+            } catch (error) {
+                vscode.window.showErrorMessage(vscode.l10n.t('Error opening reference: {0}', String(error)));
+            }
+        }
+    );
+
+    // Command for narrowing the sidebar to selected usage kinds
+    const filterReferencesByKindCommand = vscode.commands.registerCommand(
+        'goImplementationLens.filterReferencesByKind',
+        async () => {
+            try {
+                const counts = await sidebarProvider.getKindCounts();
+                if (counts.size === 0) {
+                    vscode.window.showInformationMessage(vscode.l10n.t('No references to filter'));
+                    return;
+                }
+                
+                const activeFilter = sidebarProvider.getKindFilter();
+                const items = [...counts.entries()].map(([kind, count]) => ({
+                    label: getReferenceKindLabel(kind),
+                    description: `${count}`,
+                    picked: activeFilter ? activeFilter.has(kind) : false,
+                    kind: kind
+                }));
+                
+                const selected = await vscode.window.showQuickPick(items, {
+                    placeHolder: vscode.l10n.t('Show only these kinds of references'),
+                    canPickMany: true
+                });
+                
+                if (selected) {
+                    sidebarProvider.setKindFilter(new Set(selected.map(item => item.kind)));
+                }
+            } catch (error) {
+                vscode.window.showErrorMessage(vscode.l10n.t('Error filtering references: {0}', String(error)));
+            }
+        }
+    );
+
+    // Command for searching the sidebar by file path, enclosing function or line text
+    const searchReferencesCommand = vscode.commands.registerCommand(
+        'goImplementationLens.searchReferences',
+        () => {
+            const inputBox = vscode.window.createInputBox();
+            inputBox.title = vscode.l10n.t('Search References');
+            inputBox.placeholder = vscode.l10n.t('Filter by file, function or code (use /pattern/ for a regular expression)');
+            inputBox.value = sidebarProvider.getSearchQuery() || '';
+            
+            // Narrow the tree as the user types
+            inputBox.onDidChangeValue(value => {
+                inputBox.validationMessage = sidebarProvider.setSearchQuery(value);
+            });
+            inputBox.onDidAccept(() => inputBox.hide());
+            inputBox.onDidHide(() => inputBox.dispose());
+            inputBox.show();
+        }
+    );
+
+    // Command for exporting the sidebar results for refactor planning
+    const exportReferencesCommand = vscode.commands.registerCommand(
+        'goImplementationLens.exportReferences',
+        async () => {
+            try {
+                const rows = await sidebarProvider.getExportRows();
+                if (rows.length === 0) {
+                    vscode.window.showInformationMessage(vscode.l10n.t('No references to export'));
+                    return;
+                }
+                
+                const formats: { label: string, format: ExportFormat }[] = [
+                    { label: 'CSV', format: 'csv' },
+                    { label: 'JSON', format: 'json' },
+                    { label: vscode.l10n.t('Markdown Checklist'), format: 'markdown' }
+                ];
+                const format = await vscode.window.showQuickPick(formats, {
+                    placeHolder: isSingular(rows.length)
+                        ? vscode.l10n.t('Export {0} item as', rows.length)
+                        : vscode.l10n.t('Export {0} items as', rows.length)
+                });
+                if (!format) {
+                    return;
+                }
+                
+                const copyToClipboard = vscode.l10n.t('Copy to Clipboard');
+                const destination = await vscode.window.showQuickPick([copyToClipboard, vscode.l10n.t('Save to File...')], {
+                    placeHolder: vscode.l10n.t('Export to')
+                });
+                if (!destination) {
+                    return;
+                }
+                
+                const content = formatReferences(rows, format.format, sidebarProvider.getTitle());
+                if (destination === copyToClipboard) {
+                    await vscode.env.clipboard.writeText(content);
+                    vscode.window.showInformationMessage(isSingular(rows.length)
+                        ? vscode.l10n.t('Copied {0} item to the clipboard', rows.length)
+                        : vscode.l10n.t('Copied {0} items to the clipboard', rows.length));
+                } else {
+                    const extension = EXPORT_FILE_EXTENSIONS[format.format];
+                    const uri = await vscode.window.showSaveDialog({
+                        filters: { [format.label]: [extension] },
+                        defaultUri: vscode.workspace.workspaceFolders
+                            ? vscode.Uri.joinPath(vscode.workspace.workspaceFolders[0].uri, `references.${extension}`)
+                            : undefined
+                    });
+                    if (uri) {
+                        await vscode.workspace.fs.writeFile(uri, Buffer.from(content, 'utf8'));
+                        vscode.window.showInformationMessage(isSingular(rows.length)
+                            ? vscode.l10n.t('Exported {0} item to {1}', rows.length, vscode.workspace.asRelativePath(uri))
+                            : vscode.l10n.t('Exported {0} items to {1}', rows.length, vscode.workspace.asRelativePath(uri)));
+                    }
+                }
             } catch (error) {
-                vscode.window.showErrorMessage(`Error opening reference: ${error}`);
+                vscode.window.showErrorMessage(vscode.l10n.t('Error exporting references: {0}', String(error)));
             }
         }
     );
 
+    // Command behind the line number context menu and the gutter icon hovers
+    const navigateFromGutterCommand = vscode.commands.registerCommand(
+        'goImplementationLens.navigateFromGutter',
+        (target?: GutterLineTarget) => gutterProvider.navigateFromLine(target)
+    );
+
+    const clearReferenceFilterCommand = vscode.commands.registerCommand(
+        'goImplementationLens.clearReferenceFilter',
+        () => sidebarProvider.clearFilters()
+    );
+
     context.subscriptions.push(
         codeLensProviderDisposable,
+        inlayHintsProviderDisposable,
         activeEditorChangeDisposable,
         toggleCodeLensCommand,
         toggleGutterIconsCommand,
@@ -248,44 +585,176 @@ export function activate(context: vscode.ExtensionContext) {
         goToInterfaceCommand,
         goToInterfaceDefinitionsCommand,
         openReferenceCommand,
+        goToImplementationsAtCursorCommand,
+        goToImplementedInterfacesCommand,
+        analyzeInterfaceSegregationCommand,
+        renameInterfaceMethodCommand,
+        changeInterfaceMethodSignatureCommand,
+        goToInterfaceMethodCommand,
+        nextImplementationCommand,
+        previousImplementationCommand,
+        filterReferencesByKindCommand,
+        searchReferencesCommand,
+        exportReferencesCommand,
+        clearReferenceFilterCommand,
+        navigateFromGutterCommand,
         gutterProvider,
         sidebarView
     );
 
-    // Refresh both CodeLens and gutter decorations when documents change
-    vscode.workspace.onDidChangeTextDocument((e) => {
-        if (e.document.languageId === 'go') {
-            goAnalyzer.invalidateCache(e.document.uri.fsPath);
-            codeLensProvider.refresh();
-            const editor = vscode.window.visibleTextEditors.find(ed => ed.document === e.document);
-            if (editor) {
-                gutterProvider.updateDecorations(editor);
-            }
-        }
+    // Refresh CodeLens, inlay hints, gutter decorations and the sidebar once typing pauses
+    const refreshScheduler = new RefreshScheduler(goAnalyzer, codeLensProvider, inlayHintsProvider, gutterProvider, sidebarProvider);
+    context.subscriptions.push(refreshScheduler, statusBar);
+
+    // Interface health diagnostics and their sidebar section follow the same refreshes
+    const healthProvider = new InterfaceHealthProvider(goAnalyzer);
+    const healthView = vscode.window.createTreeView('goInterfaceHealthView', {
+        treeDataProvider: healthProvider,
+        showCollapseAll: true
     });
+    context.subscriptions.push(
+        healthProvider,
+        healthView,
+        refreshScheduler.onDidRefresh(event => healthProvider.refresh(event))
+    );
 
-    vscode.workspace.onDidSaveTextDocument((document) => {
-        if (document.languageId === 'go') {
-            goAnalyzer.invalidateCache(document.uri.fsPath);
-            codeLensProvider.refresh();
-            const editor = vscode.window.visibleTextEditors.find(ed => ed.document === document);
-            if (editor) {
-                gutterProvider.updateDecorations(editor);
+    // "Generate mock" lens on interfaces; generated mocks are kept in sync when the interface is saved
+    const mockGenerator = new MockGenerator(goAnalyzer, refreshScheduler.onDidRefresh);
+    const generateMockCommand = vscode.commands.registerCommand(
+        'goImplementationLens.generateMock',
+        async (uri?: string, position?: { line: number, character: number }) => {
+            try {
+                const editor = vscode.window.activeTextEditor;
+                const document = uri ? await vscode.workspace.openTextDocument(vscode.Uri.parse(uri)) : editor?.document;
+                const target = position ? new vscode.Position(position.line, position.character) : editor?.selection.active;
+                if (!document || !target || document.languageId !== 'go') {
+                    return;
+                }
+
+                const { interfaceInfo } = await goAnalyzer.findSymbolsAt(document, target);
+                if (!interfaceInfo) {
+                    vscode.window.showInformationMessage(vscode.l10n.t('No interface with implementations at the cursor'));
+                    return;
+                }
+                if (hasEmbeddedInterfaces(document, interfaceInfo)) {
+                    vscode.window.showWarningMessage(vscode.l10n.t('{0} embeds other interfaces, whose methods cannot be mocked yet', interfaceInfo.name));
+                    return;
+                }
+
+                // Never overwrite a file we didn't generate from this interface without asking
+                const mockUri = mockGenerator.getMockUri(document.uri, interfaceInfo.name);
+                const existing = await mockGenerator.readMock(mockUri);
+                if (existing && existing.header?.interfaceName !== interfaceInfo.name) {
+                    const overwrite = vscode.l10n.t('Overwrite');
+                    const answer = await vscode.window.showWarningMessage(
+                        vscode.l10n.t('{0} already exists and was not generated from {1}. Overwrite it?', vscode.workspace.asRelativePath(mockUri), interfaceInfo.name),
+                        { modal: true },
+                        overwrite
+                    );
+                    if (answer !== overwrite) {
+                        return;
+                    }
+                }
+
+                await mockGenerator.generate(document, interfaceInfo);
+                await vscode.window.showTextDocument(mockUri, { viewColumn: vscode.ViewColumn.Beside, preserveFocus: true, preview: true });
+            } catch (error) {
+                vscode.window.showErrorMessage(vscode.l10n.t('Error generating the mock: {0}', error instanceof Error ? error.message : String(error)));
             }
         }
-    });
+    );
+    context.subscriptions.push(
+        mockGenerator,
+        generateMockCommand,
+        codeLensProvider.registry.register(generateMockLensContributor)
+    );
 
-    // Refresh when configuration changes
-    vscode.workspace.onDidChangeConfiguration((e) => {
-        if (e.affectsConfiguration('goImplementationLens')) {
-            codeLensProvider.refresh();
-            vscode.window.visibleTextEditors.forEach(editor => {
-                if (editor.document.languageId === 'go') {
-                    gutterProvider.updateDecorations(editor);
+    // Command behind the status bar item
+    const showStatusMenuCommand = vscode.commands.registerCommand(
+        'goImplementationLens.showStatusMenu',
+        async () => {
+            const config = vscode.workspace.getConfiguration('goImplementationLens');
+            const state = (enabled: boolean) => enabled ? vscode.l10n.t('On') : vscode.l10n.t('Off');
+            const items: (vscode.QuickPickItem & { run: () => unknown })[] = [
+                {
+                    label: `$(refresh) ${vscode.l10n.t('Refresh')}`,
+                    detail: vscode.l10n.t('Re-analyze all open Go files'),
+                    run: () => refreshScheduler.refreshAll()
+                },
+                {
+                    label: `$(eye) ${vscode.l10n.t('Toggle CodeLens')}`,
+                    description: state(config.get<boolean>('enable', true)),
+                    run: () => vscode.commands.executeCommand('goImplementationLens.toggleCodeLens')
+                },
+                {
+                    label: `$(debug-breakpoint-log) ${vscode.l10n.t('Toggle Gutter Icons')}`,
+                    description: state(config.get<boolean>('showGutterIcons', true)),
+                    run: () => vscode.commands.executeCommand('goImplementationLens.toggleGutterIcons')
+                },
+                {
+                    label: `$(references) ${vscode.l10n.t('Toggle References')}`,
+                    description: state(config.get<boolean>('showReferences', true)),
+                    run: () => config.update('showReferences', !config.get<boolean>('showReferences', true), vscode.ConfigurationTarget.Global)
+                },
+                {
+                    label: `$(output) ${vscode.l10n.t('Show Diagnostics Log')}`,
+                    run: () => getLog().show()
+                },
+                {
+                    label: `$(json) ${vscode.l10n.t('Dump Analyzer State')}`,
+                    run: () => vscode.commands.executeCommand('goImplementationLens.dumpAnalyzerState')
                 }
-            });
+            ];
+
+            const selected = await vscode.window.showQuickPick(items, { title: vscode.l10n.t('Go Implementation Lens') });
+            if (selected) {
+                await selected.run();
+            }
+        }
+    );
+    context.subscriptions.push(showStatusMenuCommand);
+
+    // Command for attaching the analyzer caches to bug reports
+    const dumpAnalyzerStateCommand = vscode.commands.registerCommand(
+        'goImplementationLens.dumpAnalyzerState',
+        async () => {
+            const state = { timestamp: new Date().toISOString(), ...goAnalyzer.getStateSnapshot() };
+            const document = await vscode.workspace.openTextDocument({ language: 'json', content: JSON.stringify(state, null, 2) });
+            await vscode.window.showTextDocument(document);
+            getLog().info('Dumped analyzer state', goAnalyzer.getCacheStats());
+        }
+    );
+    context.subscriptions.push(dumpAnalyzerStateCommand);
+
+    // Anything analyzed before gopls finished loading came back empty, so re-analyze once it is ready
+    const goplsReadiness = new vscode.CancellationTokenSource();
+    context.subscriptions.push(goplsReadiness);
+    statusBar.setIndexing(true);
+    waitForGopls(goplsReadiness.token).then(async readiness => {
+        statusBar.setIndexing(false);
+        if (readiness === 'ready') {
+            refreshScheduler.refreshAll();
+        } else if (readiness === 'timedOut') {
+            getLog().warn('gopls did not answer document symbol requests in time; results stay incomplete until files are edited or saved');
+            const showLog = vscode.l10n.t('Show Diagnostics Log');
+            if (await vscode.window.showWarningMessage(vscode.l10n.t('gopls has not finished loading the workspace, so implementations may be missing until you edit or save a file. Is the Go extension installed and gopls running?'), showLog) === showLog) {
+                getLog().show();
+            }
         }
     });
+
+    // Package paths in the sidebar are derived from go.mod, so forget them when a module file changes
+    const goModWatcher = vscode.workspace.createFileSystemWatcher('**/go.mod');
+    goModWatcher.onDidChange(() => goAnalyzer.invalidateModuleCache());
+    goModWatcher.onDidCreate(() => goAnalyzer.invalidateModuleCache());
+    goModWatcher.onDidDelete(() => goAnalyzer.invalidateModuleCache());
+    context.subscriptions.push(goModWatcher);
+
+    // Resolved names and symbols are shared across files, so drop them when a file changes outside the editor
+    const goFileWatcher = vscode.workspace.createFileSystemWatcher('**/*.go', true, false, false);
+    goFileWatcher.onDidChange(uri => goAnalyzer.invalidateCache(uri.fsPath));
+    goFileWatcher.onDidDelete(uri => goAnalyzer.invalidateCache(uri.fsPath));
+    context.subscriptions.push(goFileWatcher);
 }
 
 export function deactivate() {}
@@ -297,6 +766,152 @@ async function goToLocation(location: vscode.Location) {
     editor.revealRange(location.range, vscode.TextEditorRevealType.InCenter);
 }
 
+type NavigationMode = 'sidebar' | 'quickPick' | 'peek';
+
+interface LocationResults {
+    implementations: vscode.Location[];
+    references: vscode.Location[];
+    symbolName?: string;
+    symbolKind?: vscode.SymbolKind;
+    // Overrides the sidebar heading, e.g. for the interfaces a type implements
+    title?: string;
+    quickPickTitle: string;
+    // Where the peek widget is anchored, usually the declaration the CodeLens sits on
+    anchor?: vscode.Location;
+}
+
+function getNavigationMode(): NavigationMode {
+    const config = vscode.workspace.getConfiguration('goImplementationLens');
+    const inspected = config.inspect<NavigationMode>('navigationMode');
+    const explicitMode = inspected?.workspaceFolderValue ?? inspected?.workspaceValue ?? inspected?.globalValue;
+    if (explicitMode) {
+        return explicitMode;
+    }
+    
+    // Fall back to the older boolean setting until navigationMode is set
+    return config.get<boolean>('useSidebar', true) ? 'sidebar' : 'quickPick';
+}
+
+async function showLocations(sidebarProvider: GoReferenceSidebarProvider, results: LocationResults) {
+    const allLocations = [...results.implementations, ...results.references];
+    const mode = getNavigationMode();
+    
+    if (allLocations.length === 1) {
+        // Single target - navigate directly
+        await goToLocation(allLocations[0]);
+    } else if (mode === 'sidebar') {
+        // Update the sidebar and show it, even when empty so the user sees the empty state
+        sidebarProvider.updateReferences(results.symbolName || 'Symbol', results.implementations, results.references, results.symbolKind, results.title);
+        await vscode.commands.executeCommand('workbench.view.extension.goReferences');
+    } else if (allLocations.length === 0) {
+        return;
+    } else if (mode === 'peek') {
+        await peekLocations(allLocations, results.quickPickTitle, results.anchor);
+    } else {
+        await showQuickPickForLocations(
+            results.implementations.length > 0 ? results.implementations : results.references,
+            results.quickPickTitle
+        );
+    }
+}
+
+async function getImplementationCycle(
+    goAnalyzer: GoAnalyzer,
+    document: vscode.TextDocument,
+    position: vscode.Position
+): Promise<{ locations: vscode.Location[], currentIndex: number } | undefined> {
+    const atCursor = await goAnalyzer.findSymbolsAt(document, position);
+    const config = vscode.workspace.getConfiguration('goImplementationLens');
+    
+    // On an interface: cycle through its implementations, starting from outside the list
+    if (atCursor.interfaceInfo) {
+        const locations = getShownImplementations(atCursor.interfaceMethod || atCursor.interfaceInfo, config);
+        return { locations, currentIndex: -1 };
+    }
+    
+    // On an implementation: cycle through the siblings implementing the same interface (method)
+    let locations: vscode.Location[] | undefined;
+    let currentRange: vscode.Range | undefined;
+    if (atCursor.methodImplementation?.interfaceMethod) {
+        const target = await goAnalyzer.findInterfaceAt(atCursor.methodImplementation.interfaceMethod);
+        locations = target.interfaceMethod && getShownImplementations(target.interfaceMethod, config);
+        currentRange = atCursor.methodImplementation.range;
+    } else if (atCursor.typeInfo && atCursor.typeInfo.implementedInterfaces.length > 0) {
+        const target = await goAnalyzer.findInterfaceAt(atCursor.typeInfo.implementedInterfaces[0]);
+        locations = target.interfaceInfo && getShownImplementations(target.interfaceInfo, config);
+        currentRange = atCursor.typeInfo.range;
+    }
+    
+    if (!locations || !currentRange) {
+        return undefined;
+    }
+    
+    const range = currentRange;
+    const currentIndex = locations.findIndex(location =>
+        location.uri.toString() === document.uri.toString() && range.contains(location.range.start));
+    return { locations, currentIndex };
+}
+
+/**
+ * Collects what a refactoring of the interface method edits. Refuses implementations outside the
+ * workspace and asks before breaking other interfaces; undefined when refused or cancelled.
+ */
+async function findConfirmedMethodTargets(goAnalyzer: GoAnalyzer, target: InterfaceMethodTarget): Promise<MethodTargets | undefined> {
+    const { interfaceInfo, method } = target;
+    const targets = await vscode.window.withProgress({
+        location: vscode.ProgressLocation.Notification,
+        title: vscode.l10n.t('Finding implementations and call sites of {0}.{1}…', interfaceInfo.name, method.name),
+        cancellable: true
+    }, (_progress, token) => findMethodTargets(goAnalyzer, target.document, method, token));
+    if (!targets) {
+        return undefined;
+    }
+
+    if (targets.externalImplementations.length > 0) {
+        vscode.window.showErrorMessage(vscode.l10n.t('{0}.{1} is also implemented outside the workspace, in {2}, which cannot be changed',
+            interfaceInfo.name, method.name, vscode.workspace.asRelativePath(targets.externalImplementations[0].uri)));
+        return undefined;
+    }
+    if (targets.otherInterfaceMethods.length > 0) {
+        const proceed = vscode.l10n.t('Continue');
+        const answer = await vscode.window.showWarningMessage(
+            vscode.l10n.t('The implementations of {0}.{1} also implement {2}, which they will no longer satisfy. Continue?',
+                interfaceInfo.name, method.name, targets.otherInterfaceMethods.join(', ')),
+            { modal: true },
+            proceed
+        );
+        if (answer !== proceed) {
+            return undefined;
+        }
+    }
+    return targets;
+}
+
+async function peekLocations(locations: vscode.Location[], title: string, anchor?: vscode.Location) {
+    const editor = vscode.window.activeTextEditor;
+    const uri = anchor ? anchor.uri : editor?.document.uri;
+    const position = anchor ? anchor.range.start : editor?.selection.active;
+    
+    if (!uri || !position) {
+        // The peek widget needs an editor to live in
+        await showQuickPickForLocations(locations, title);
+        return;
+    }
+    
+    await vscode.commands.executeCommand('editor.action.peekLocations', uri, position, locations, 'peek');
+}
+
+async function previewLocation(location: vscode.Location) {
+    // Show the location beside the current editor in a preview tab, without moving focus
+    const document = await vscode.workspace.openTextDocument(location.uri);
+    await vscode.window.showTextDocument(document, {
+        viewColumn: vscode.ViewColumn.Beside,
+        preserveFocus: true,
+        preview: true,
+        selection: new vscode.Range(location.range.start, location.range.start)
+    });
+}
+
 async function showQuickPickForLocations(locations: vscode.Location[], title: string) {
     if (locations.length === 0) {
         return;
@@ -323,7 +938,7 @@ async function showQuickPickForLocations(locations: vscode.Location[], title: st
             return {
                 label: `$(file-code) ${fileName}`,
                 description: folderPath,
-                detail: `Line ${location.range.start.line + 1}: ${line.text.trim()}`,
+                detail: vscode.l10n.t('Line {0}: {1}', location.range.start.line + 1, line.text.trim()),
                 location: location
             };
         } catch (error) {
@@ -332,19 +947,44 @@ async function showQuickPickForLocations(locations: vscode.Location[], title: st
             return {
                 label: `$(file-code) ${fileName}`,
                 description: vscode.workspace.asRelativePath(location.uri),
-                detail: `Line ${location.range.start.line + 1}`,
+                detail: vscode.l10n.t('Line {0}', location.range.start.line + 1),
                 location: location
             };
         }
     }));
 
-    const selected = await vscode.window.showQuickPick(items, {
-        placeHolder: `${title} (${locations.length} found)`,
-        matchOnDescription: true,
-        matchOnDetail: true
-    });
+    const config = vscode.workspace.getConfiguration('goImplementationLens');
+    if (!config.get<boolean>('previewInSidePane', false)) {
+        const selected = await vscode.window.showQuickPick(items, {
+            placeHolder: vscode.l10n.t('{0} ({1} found)', title, locations.length),
+            matchOnDescription: true,
+            matchOnDetail: true
+        });
 
-    if (selected) {
-        await goToLocation(selected.location);
+        if (selected) {
+            await goToLocation(selected.location);
+        }
+        return;
     }
+
+    // Preview each entry beside the current editor as the user moves through the list
+    const quickPick = vscode.window.createQuickPick<typeof items[number]>();
+    quickPick.items = items;
+    quickPick.placeholder = vscode.l10n.t('{0} ({1} found)', title, locations.length);
+    quickPick.matchOnDescription = true;
+    quickPick.matchOnDetail = true;
+    quickPick.onDidChangeActive(active => {
+        if (active.length > 0) {
+            previewLocation(active[0].location).catch(() => undefined);
+        }
+    });
+    quickPick.onDidAccept(async () => {
+        const selected = quickPick.selectedItems[0];
+        quickPick.hide();
+        if (selected) {
+            await goToLocation(selected.location);
+        }
+    });
+    quickPick.onDidHide(() => quickPick.dispose());
+    quickPick.show();
 }
\ No newline at end of file
@@ -1,11 +1,15 @@
 import * as vscode from 'vscode';
-
+import * as path from 'path';
+import { getLog } from './logger';
+import { classifyFile, ImplementationCategory, ImplementationsByCategory } from './implementationCategory';
 
 export interface InterfaceInfo {
     name: string;
     range: vscode.Range;
     uri: vscode.Uri;
     implementations: vscode.Location[];
+    // The same implementations, split by whether they live in regular code, tests or mocks
+    implementationsByCategory: ImplementationsByCategory;
     references: vscode.Location[];
     methods: MethodInfo[];
 }
@@ -14,6 +18,7 @@ export interface MethodInfo {
     name: string;
     range: vscode.Range;
     implementations: vscode.Location[];
+    implementationsByCategory: ImplementationsByCategory;
     references: vscode.Location[];
 }
 
@@ -22,13 +27,35 @@ export interface TypeInfo {
     range: vscode.Range;
     uri: vscode.Uri;
     implementedInterfaces: vscode.Location[];
+    // Names of implementedInterfaces, resolved once during analysis; unresolvable ones are left out
+    implementedInterfaceNames: string[];
     methods: TypeMethodInfo[];
+    // Unqualified names of embedded fields, e.g. "Reader" for "io.Reader" or "*Base"
+    embeddedTypes: string[];
 }
 
 export interface TypeMethodInfo {
     name: string;
     range: vscode.Range;
     interfaceMethod?: vscode.Location;
+    // "Interface.Method" for interfaceMethod, resolved once during analysis
+    interfaceMethodName?: string;
+    interfaceMethods: vscode.Location[];
+    receiverType?: string;
+}
+
+export interface AnalysisResult {
+    interfaces: InterfaceInfo[];
+    types: TypeInfo[];
+    methodImplementations: TypeMethodInfo[];
+    symbolReferences: SymbolReferenceInfo[];
+}
+
+export interface SymbolsAtPosition {
+    interfaceInfo?: InterfaceInfo;
+    interfaceMethod?: MethodInfo;
+    typeInfo?: TypeInfo;
+    methodImplementation?: TypeMethodInfo;
 }
 
 export interface SymbolReferenceInfo {
@@ -39,19 +66,101 @@ export interface SymbolReferenceInfo {
     kind: vscode.SymbolKind;
 }
 
+interface GoModule {
+    root: string;
+    path: string;
+}
+
+export interface GoplsHealth {
+    // Set while the most recent gopls request failed
+    failing: boolean;
+    lastError?: string;
+}
+
+export interface CacheStats {
+    analysisHits: number;
+    analysisMisses: number;
+    nameHits: number;
+    nameMisses: number;
+    symbolHits: number;
+    symbolMisses: number;
+    goplsRequests: number;
+    goplsFailures: number;
+}
+
 export class GoAnalyzer {
+    private _onDidChangeActivity: vscode.EventEmitter<void> = new vscode.EventEmitter<void>();
+    // Fires when an analysis starts or finishes, or gopls starts or stops failing
+    public readonly onDidChangeActivity: vscode.Event<void> = this._onDidChangeActivity.event;
+    private goplsHealth: GoplsHealth = { failing: false };
+    private stats: CacheStats = {
+        analysisHits: 0,
+        analysisMisses: 0,
+        nameHits: 0,
+        nameMisses: 0,
+        symbolHits: 0,
+        symbolMisses: 0,
+        goplsRequests: 0,
+        goplsFailures: 0
+    };
+
+    private moduleCache: Map<string, GoModule | null> = new Map();
+    // Resolved symbol names by file, then by "kind:line:character", shared by all features
+    private nameCache: Map<string, Map<string, string>> = new Map();
+    // Analyzed files by the files the interface names in their analysis were resolved in
+    private nameDependents: Map<string, Set<string>> = new Map();
+    // Document symbols of files that names and enclosing functions were looked up in
+    private symbolCache: Map<string, vscode.DocumentSymbol[]> = new Map();
+    // Category of each file implementations were found in
+    private categoryCache: Map<string, ImplementationCategory> = new Map();
     private cache: Map<string, { interfaces: InterfaceInfo[], types: TypeInfo[], methodImplementations: TypeMethodInfo[], symbolReferences: SymbolReferenceInfo[], documentVersion: number }> = new Map();
+    // Files edited since their last analysis that still have a refresh pending
+    private pendingChanges: Set<string> = new Set();
+    // Analyses in progress by "path@version", so concurrent callers share one
+    private inFlight: Map<string, Promise<AnalysisResult>> = new Map();
+
+    /**
+     * Keeps serving the previous analysis of an edited file until invalidateCache is called,
+     * so providers queried while the user is typing don't each start a re-analysis.
+     */
+    markChanged(filePath: string) {
+        this.pendingChanges.add(filePath);
+    }
+
+    /**
+     * Drops the analyses kept for edited files, for refactorings that edit at the positions they find.
+     */
+    invalidatePendingChanges() {
+        for (const filePath of [...this.pendingChanges]) {
+            this.invalidateCache(filePath);
+        }
+    }
 
     invalidateCache(filePath: string) {
         this.cache.delete(filePath);
+        this.pendingChanges.delete(filePath);
+        // Names resolved from this file may have moved or been renamed
+        this.nameCache.delete(filePath);
+        this.symbolCache.delete(filePath);
+        this.categoryCache.delete(filePath);
+        // Analyses of other files showing those names are stale too, e.g. after the interface was renamed
+        for (const dependent of this.nameDependents.get(filePath) || []) {
+            this.cache.delete(dependent);
+        }
+        this.nameDependents.delete(filePath);
+    }
+
+    invalidateModuleCache() {
+        this.moduleCache.clear();
     }
 
 
-    async analyzeDocument(document: vscode.TextDocument): Promise<{ interfaces: InterfaceInfo[], types: TypeInfo[], methodImplementations: TypeMethodInfo[], symbolReferences: SymbolReferenceInfo[] }> {
+    async analyzeDocument(document: vscode.TextDocument): Promise<AnalysisResult> {
         const currentVersion = document.version;
         const cached = this.cache.get(document.uri.fsPath);
         
-        if (cached && cached.documentVersion === currentVersion) {
+        if (cached && (cached.documentVersion === currentVersion || this.pendingChanges.has(document.uri.fsPath))) {
+            this.stats.analysisHits++;
             return { 
                 interfaces: cached.interfaces, 
                 types: cached.types, 
@@ -60,10 +169,123 @@ export class GoAnalyzer {
             };
         }
 
-        const symbols = await vscode.commands.executeCommand<vscode.DocumentSymbol[]>(
-            'vscode.executeDocumentSymbolProvider',
-            document.uri
-        );
+        const key = `${document.uri.fsPath}@${currentVersion}`;
+        let analysis = this.inFlight.get(key);
+        if (!analysis) {
+            this.stats.analysisMisses++;
+            analysis = this.analyzeUncached(document).finally(() => {
+                this.inFlight.delete(key);
+                this._onDidChangeActivity.fire();
+            });
+            this.inFlight.set(key, analysis);
+            this._onDidChangeActivity.fire();
+        }
+        return analysis;
+    }
+
+    isAnalyzing(): boolean {
+        return this.inFlight.size > 0;
+    }
+
+    getGoplsHealth(): GoplsHealth {
+        return this.goplsHealth;
+    }
+
+    /**
+     * The last analysis of a document, without starting a new one.
+     */
+    getCachedAnalysis(document: vscode.TextDocument): AnalysisResult | undefined {
+        const cached = this.cache.get(document.uri.fsPath);
+        if (!cached) {
+            return undefined;
+        }
+        return {
+            interfaces: cached.interfaces,
+            types: cached.types,
+            methodImplementations: cached.methodImplementations,
+            symbolReferences: cached.symbolReferences
+        };
+    }
+
+    getCacheStats(): CacheStats {
+        return { ...this.stats };
+    }
+
+    /**
+     * A JSON-serializable copy of all caches, for attaching to bug reports.
+     */
+    getStateSnapshot(): object {
+        const location = (loc: vscode.Location) =>
+            `${vscode.workspace.asRelativePath(loc.uri)}:${loc.range.start.line + 1}:${loc.range.start.character + 1}`;
+        const line = (range: vscode.Range) => range.start.line + 1;
+
+        const analyses: Record<string, object> = {};
+        for (const [filePath, cached] of this.cache) {
+            analyses[vscode.workspace.asRelativePath(filePath)] = {
+                documentVersion: cached.documentVersion,
+                pendingChange: this.pendingChanges.has(filePath),
+                interfaces: cached.interfaces.map(interfaceInfo => ({
+                    name: interfaceInfo.name,
+                    line: line(interfaceInfo.range),
+                    implementations: interfaceInfo.implementations.map(location),
+                    testImplementations: interfaceInfo.implementationsByCategory.test.length,
+                    mockImplementations: interfaceInfo.implementationsByCategory.mock.length,
+                    references: interfaceInfo.references.length,
+                    methods: interfaceInfo.methods.map(method => ({
+                        name: method.name,
+                        line: line(method.range),
+                        implementations: method.implementations.map(location),
+                        references: method.references.length
+                    }))
+                })),
+                types: cached.types.map(typeInfo => ({
+                    name: typeInfo.name,
+                    line: line(typeInfo.range),
+                    implementedInterfaces: typeInfo.implementedInterfaces.map(location),
+                    implementedInterfaceNames: typeInfo.implementedInterfaceNames,
+                    embeddedTypes: typeInfo.embeddedTypes
+                })),
+                methodImplementations: cached.methodImplementations.map(methodImpl => ({
+                    name: methodImpl.name,
+                    line: line(methodImpl.range),
+                    receiverType: methodImpl.receiverType,
+                    interfaceMethodName: methodImpl.interfaceMethodName,
+                    interfaceMethods: methodImpl.interfaceMethods.map(location)
+                })),
+                symbolReferences: cached.symbolReferences.map(symbolRef => ({
+                    name: symbolRef.name,
+                    line: line(symbolRef.range),
+                    kind: vscode.SymbolKind[symbolRef.kind],
+                    references: symbolRef.references.length
+                }))
+            };
+        }
+
+        const names: Record<string, Record<string, string>> = {};
+        for (const [filePath, fileNames] of this.nameCache) {
+            names[vscode.workspace.asRelativePath(filePath)] = Object.fromEntries(fileNames);
+        }
+
+        const modules: Record<string, string | null> = {};
+        for (const [dir, module] of this.moduleCache) {
+            modules[dir] = module ? `${module.path} (${module.root})` : null;
+        }
+
+        return {
+            stats: this.stats,
+            gopls: this.goplsHealth,
+            analysesInProgress: Array.from(this.inFlight.keys(), key => vscode.workspace.asRelativePath(key)),
+            analyses: analyses,
+            names: names,
+            documentSymbols: Array.from(this.symbolCache.keys(), filePath => vscode.workspace.asRelativePath(filePath)),
+            modules: modules
+        };
+    }
+
+    private async analyzeUncached(document: vscode.TextDocument): Promise<AnalysisResult> {
+        const currentVersion = document.version;
+        const start = Date.now();
+        const symbols = await this.goplsRequest<vscode.DocumentSymbol[]>('documentSymbol', 'vscode.executeDocumentSymbolProvider', document.uri);
 
         if (!symbols) {
             return { interfaces: [], types: [], methodImplementations: [], symbolReferences: [] };
@@ -79,6 +301,8 @@ export class GoAnalyzer {
 
         const result = { interfaces, types, methodImplementations, symbolReferences, documentVersion: currentVersion };
         this.cache.set(document.uri.fsPath, result);
+        getLog().debug(`Analyzed ${vscode.workspace.asRelativePath(document.uri)} (version ${currentVersion}) in ${Date.now() - start} ms: ` +
+            `${interfaces.length} interfaces, ${types.length} implementing types, ${methodImplementations.length} methods, ${symbolReferences.length} symbols with references`);
         return { interfaces, types, methodImplementations, symbolReferences };
     }
 
@@ -153,30 +377,55 @@ export class GoAnalyzer {
     }
 
     private async getImplementationsFromGopls(uri: vscode.Uri, position: vscode.Position): Promise<vscode.Location[]> {
-        try {
-            const implementations = await vscode.commands.executeCommand<vscode.Location[]>(
-                'vscode.executeImplementationProvider',
-                uri,
-                position
-            );
-            
-            return implementations || [];
-        } catch (error) {
-            return [];
-        }
+        const implementations = await this.goplsRequest<vscode.Location[]>('implementation', 'vscode.executeImplementationProvider', uri, position);
+        return implementations || [];
     }
 
     private async getReferencesFromGopls(uri: vscode.Uri, position: vscode.Position): Promise<vscode.Location[]> {
+        const references = await this.goplsRequest<vscode.Location[]>('reference', 'vscode.executeReferenceProvider', uri, position);
+        return references || [];
+    }
+
+    /**
+     * Runs a language feature command answered by gopls, logging its timing. Failures are
+     * logged and tracked for the status bar, and yield undefined.
+     */
+    private async goplsRequest<T>(request: string, command: string, uri: vscode.Uri, position?: vscode.Position): Promise<T | undefined> {
+        const target = position
+            ? `${vscode.workspace.asRelativePath(uri)}:${position.line + 1}:${position.character + 1}`
+            : vscode.workspace.asRelativePath(uri);
+        const start = Date.now();
+        this.stats.goplsRequests++;
         try {
-            const references = await vscode.commands.executeCommand<vscode.Location[]>(
-                'vscode.executeReferenceProvider',
-                uri,
-                position
-            );
-            
-            return references || [];
+            const result = position
+                ? await vscode.commands.executeCommand<T>(command, uri, position)
+                : await vscode.commands.executeCommand<T>(command, uri);
+            const count = Array.isArray(result) ? result.length : 0;
+            getLog().trace(`gopls ${request} ${target}: ${count} results in ${Date.now() - start} ms`);
+            this.recordGoplsSuccess();
+            return result;
         } catch (error) {
-            return [];
+            this.stats.goplsFailures++;
+            this.recordGoplsFailure(`${request} ${target}`, error, Date.now() - start);
+            return undefined;
+        }
+    }
+
+    private recordGoplsSuccess() {
+        if (this.goplsHealth.failing) {
+            getLog().info('gopls requests are succeeding again');
+            this.goplsHealth = { failing: false };
+            this._onDidChangeActivity.fire();
+        }
+    }
+
+    private recordGoplsFailure(request: string, error: unknown, elapsed: number) {
+        const message = error instanceof Error ? error.message : String(error);
+        getLog().warn(`gopls ${request} failed after ${elapsed} ms: ${message}`);
+        const wasFailing = this.goplsHealth.failing;
+        this.goplsHealth = { failing: true, lastError: message };
+        if (!wasFailing) {
+            this._onDidChangeActivity.fire();
         }
     }
 
@@ -211,6 +460,7 @@ export class GoAnalyzer {
                         name: child.name,
                         range: child.range,
                         implementations: methodImplementations,
+                        implementationsByCategory: await this.categorizeImplementations(methodImplementations),
                         references: methodReferences
                     });
                 }
@@ -222,43 +472,445 @@ export class GoAnalyzer {
             range: symbol.range,
             uri: document.uri,
             implementations: implementations,
+            implementationsByCategory: await this.categorizeImplementations(implementations),
             references: references,
             methods: methods
         });
     }
 
+    private async categorizeImplementations(implementations: vscode.Location[]): Promise<ImplementationsByCategory> {
+        const byCategory: ImplementationsByCategory = { production: [], test: [], mock: [] };
+        for (const location of implementations) {
+            const filePath = location.uri.fsPath;
+            let category = this.categoryCache.get(filePath);
+            if (!category) {
+                category = await classifyFile(location.uri);
+                this.categoryCache.set(filePath, category);
+            }
+            byCategory[category].push(location);
+        }
+        return byCategory;
+    }
+
     private async processStruct(symbol: vscode.DocumentSymbol, document: vscode.TextDocument, implementations: vscode.Location[], types: TypeInfo[]) {
         // For structs, the implementations represent interfaces this struct implements
+        const implementedInterfaceNames: string[] = [];
+        for (const location of implementations) {
+            this.addNameDependency(location, document);
+            const name = await this.getSymbolName(location);
+            if (name) {
+                implementedInterfaceNames.push(name);
+            }
+        }
+        
         types.push({
             name: symbol.name,
             range: symbol.range,
             uri: document.uri,
             implementedInterfaces: implementations,
-            methods: [] // We'll populate this if needed
+            implementedInterfaceNames: implementedInterfaceNames,
+            methods: [], // We'll populate this if needed
+            embeddedTypes: this.findEmbeddedTypes(document, symbol.range)
         });
     }
 
-    private async processMethod(symbol: vscode.DocumentSymbol, _document: vscode.TextDocument, implementations: vscode.Location[], methodImplementations: TypeMethodInfo[]) {
+    private findEmbeddedTypes(document: vscode.TextDocument, range: vscode.Range): string[] {
+        const embeddedTypes: string[] = [];
+        // The declaration line holds "type X struct {", the last line the closing brace
+        for (let line = range.start.line + 1; line < range.end.line; line++) {
+            // An embedded field is a lone (qualified, possibly pointer) type name with an optional tag
+            const match = document.lineAt(line).text.match(/^\s*\*?(?:\w+\.)?(\w+)\s*(?:`[^`]*`)?\s*(?:\/\/.*)?$/);
+            if (match) {
+                embeddedTypes.push(match[1]);
+            }
+        }
+        return embeddedTypes;
+    }
+
+    private async processMethod(symbol: vscode.DocumentSymbol, document: vscode.TextDocument, implementations: vscode.Location[], methodImplementations: TypeMethodInfo[]) {
         // For methods/functions, implementations might point to interface methods they implement
         // We'll take the first implementation as the interface method (if any)
         const interfaceMethod = implementations.length > 0 ? implementations[0] : undefined;
+        if (interfaceMethod) {
+            this.addNameDependency(interfaceMethod, document);
+        }
+        
+        // "func (r *Type[T]) Name(" - keep just the type name so it can be matched against TypeInfo
+        const receiverMatch = document.lineAt(symbol.range.start.line).text.match(/^\s*func\s*\(\s*(?:\w+\s+)?\*?\s*(\w+)/);
         
         methodImplementations.push({
             name: symbol.name,
             range: symbol.range,
-            interfaceMethod: interfaceMethod
+            interfaceMethod: interfaceMethod,
+            interfaceMethodName: interfaceMethod ? await this.getInterfaceAndMethodName(interfaceMethod) : undefined,
+            interfaceMethods: implementations,
+            receiverType: receiverMatch ? receiverMatch[1] : undefined
         });
     }
 
+    async findSymbolsAt(document: vscode.TextDocument, position: vscode.Position): Promise<SymbolsAtPosition> {
+        const { interfaces, types, methodImplementations } = await this.analyzeDocument(document);
+        const result: SymbolsAtPosition = {};
+        
+        for (const interfaceInfo of interfaces) {
+            if (interfaceInfo.range.contains(position)) {
+                result.interfaceInfo = interfaceInfo;
+                result.interfaceMethod = interfaceInfo.methods.find(method => method.range.contains(position));
+            }
+        }
+        
+        result.methodImplementation = methodImplementations.find(methodImpl => methodImpl.range.contains(position));
+        result.typeInfo = types.find(typeInfo => typeInfo.range.contains(position));
+        
+        // Go methods are declared outside their type, so fall back to the receiver type
+        if (!result.typeInfo && result.methodImplementation?.receiverType) {
+            const receiverType = result.methodImplementation.receiverType;
+            result.typeInfo = types.find(typeInfo => typeInfo.name === receiverType);
+        }
+        
+        return result;
+    }
+
+    async findInterfaceAt(location: vscode.Location): Promise<SymbolsAtPosition> {
+        // Locations from gopls point into other files, which may not have been analyzed yet
+        const document = await vscode.workspace.openTextDocument(location.uri);
+        return this.findSymbolsAt(document, location.range.start);
+    }
+
+
+
+    async getPackagePath(uri: vscode.Uri): Promise<string> {
+        const dir = path.dirname(uri.fsPath);
+        const module = await this.findModule(dir);
+        
+        if (!module) {
+            // Outside of a module we can only go by the directory name
+            return path.basename(dir) || 'unknown';
+        }
+        
+        const relativeDir = path.relative(module.root, dir).split(path.sep).join('/');
+        return relativeDir ? `${module.path}/${relativeDir}` : module.path;
+    }
+
+    private async findModule(dir: string): Promise<GoModule | undefined> {
+        const cached = this.moduleCache.get(dir);
+        if (cached !== undefined) {
+            return cached || undefined;
+        }
+        
+        let module: GoModule | undefined;
+        try {
+            const content = await vscode.workspace.fs.readFile(vscode.Uri.file(path.join(dir, 'go.mod')));
+            const match = Buffer.from(content).toString('utf8').match(/^\s*module\s+"?([^\s"]+)"?/m);
+            if (match) {
+                module = { root: dir, path: match[1] };
+            }
+        } catch (error) {
+            // No go.mod in this directory, keep walking up
+        }
+        
+        if (!module) {
+            const parent = path.dirname(dir);
+            if (parent !== dir) {
+                module = await this.findModule(parent);
+            }
+        }
+        
+        this.moduleCache.set(dir, module || null);
+        return module;
+    }
+
+    async getEnclosingSymbolNames(document: vscode.TextDocument, positions: vscode.Position[]): Promise<string[]> {
+        const symbols = await this.getDocumentSymbols(document.uri);
+        return positions.map(position => this.describeEnclosingSymbol(document, symbols || [], position));
+    }
+
+    private describeEnclosingSymbol(document: vscode.TextDocument, symbols: vscode.DocumentSymbol[], position: vscode.Position): string {
+        const chain = this.findContainingSymbols(symbols, position);
+        if (chain.length === 0) {
+            return vscode.l10n.t('global');
+        }
+        
+        // Prefer the innermost function or method, since that is where the code actually lives
+        const func = [...chain].reverse().find(symbol =>
+            symbol.kind === vscode.SymbolKind.Function || symbol.kind === vscode.SymbolKind.Method);
+        
+        if (func) {
+            const text = document.getText(new vscode.Range(func.range.start, position));
+            // Interface methods are Method symbols too, but they have no "func" declaration
+            if (text.startsWith('func')) {
+                const closureDepth = this.countEnclosingFuncLiterals(text);
+                return `func ${func.name}` + ` → ${vscode.l10n.t('closure')}`.repeat(closureDepth);
+            }
+        }
+        
+        // Otherwise report the top-level declaration (type, var, const) rather than a field inside it
+        const outer = chain[0];
+        if (outer.kind === vscode.SymbolKind.Variable || outer.kind === vscode.SymbolKind.Constant) {
+            return outer.name;
+        }
+        return `type ${outer.name}`;
+    }
+
+    private findContainingSymbols(symbols: vscode.DocumentSymbol[], position: vscode.Position): vscode.DocumentSymbol[] {
+        for (const symbol of symbols) {
+            if (symbol.range.contains(position)) {
+                return [symbol, ...this.findContainingSymbols(symbol.children || [], position)];
+            }
+        }
+        return [];
+    }
+
+    private countEnclosingFuncLiterals(text: string): number {
+        // Start inside the declaration's body, so func types in its own signature are not mistaken for literals
+        const bodyStart = this.findFuncDeclarationBody(text);
+        if (bodyStart === -1) {
+            return 0;
+        }
+        
+        let i = bodyStart + 1;
+        const literalDepths: number[] = [];
+        let depth = 1;
+        
+        while (i < text.length) {
+            const ch = text[i];
+            
+            if (ch === '/' && (text[i + 1] === '/' || text[i + 1] === '*')) {
+                i = this.skipComment(text, i);
+                continue;
+            }
+            if (ch === '"' || ch === '\'' || ch === '`') {
+                i = this.skipStringLiteral(text, i);
+                continue;
+            }
+            
+            if (ch === '{') {
+                depth++;
+            } else if (ch === '}') {
+                if (literalDepths.length > 0 && literalDepths[literalDepths.length - 1] === depth) {
+                    literalDepths.pop();
+                }
+                depth--;
+            } else if (this.isKeywordAt(text, i, 'func')) {
+                const bodyStart = this.findFuncLiteralBody(text, i + 4);
+                if (bodyStart !== -1) {
+                    depth++;
+                    literalDepths.push(depth);
+                    i = bodyStart + 1;
+                    continue;
+                }
+            }
+            i++;
+        }
+        
+        return literalDepths.length;
+    }
+
+    private findFuncDeclarationBody(text: string): number {
+        // Skip "func (recv) Name[T any]" so that what remains has the shape of a func literal
+        let i = 4;
+        const skipSpace = () => {
+            while (i < text.length && /\s/.test(text[i])) {
+                i++;
+            }
+        };
+        const skipBalanced = (open: string, close: string) => {
+            let depth = 0;
+            for (; i < text.length; i++) {
+                if (text[i] === open) {
+                    depth++;
+                } else if (text[i] === close && --depth === 0) {
+                    i++;
+                    return;
+                }
+            }
+        };
+        
+        skipSpace();
+        if (text[i] === '(') {
+            skipBalanced('(', ')');
+            skipSpace();
+        }
+        while (i < text.length && /\w/.test(text[i])) {
+            i++;
+        }
+        if (text[i] === '[') {
+            skipBalanced('[', ']');
+        }
+        return this.findFuncLiteralBody(text, i);
+    }
+
+    private findFuncLiteralBody(text: string, from: number): number {
+        // A func literal is "func(params) results {", whereas a func type has no body
+        let i = from;
+        while (i < text.length && /\s/.test(text[i])) {
+            i++;
+        }
+        if (text[i] !== '(') {
+            return -1;
+        }
+        
+        let depth = 0;
+        while (i < text.length) {
+            const ch = text[i];
+            if (ch === '"' || ch === '\'' || ch === '`') {
+                i = this.skipStringLiteral(text, i);
+                continue;
+            }
+            if (ch === '(' || ch === '[') {
+                depth++;
+            } else if (ch === ')' || ch === ']') {
+                depth--;
+                if (depth < 0) {
+                    return -1;
+                }
+            } else if (depth === 0) {
+                if (ch === '{') {
+                    // "interface{}" and "struct{...}" result types carry their own braces
+                    if (/\b(?:interface|struct)\s*$/.test(text.substring(from, i))) {
+                        const close = this.findMatchingBrace(text, i);
+                        if (close === -1) {
+                            return -1;
+                        }
+                        i = close + 1;
+                        continue;
+                    }
+                    return i;
+                }
+                if (ch === ',' || ch === ';' || ch === '=' || ch === '}' || ch === '\n') {
+                    return -1;
+                }
+            }
+            i++;
+        }
+        return -1;
+    }
+
+    private findMatchingBrace(text: string, open: number): number {
+        let depth = 0;
+        for (let i = open; i < text.length; i++) {
+            if (text[i] === '{') {
+                depth++;
+            } else if (text[i] === '}') {
+                depth--;
+                if (depth === 0) {
+                    return i;
+                }
+            }
+        }
+        return -1;
+    }
+
+    private isKeywordAt(text: string, index: number, keyword: string): boolean {
+        return text.startsWith(keyword, index) &&
+            (index === 0 || !/\w/.test(text[index - 1])) &&
+            !/\w/.test(text[index + keyword.length] || '');
+    }
+
+    private skipComment(text: string, start: number): number {
+        if (text[start + 1] === '/') {
+            const end = text.indexOf('\n', start);
+            return end === -1 ? text.length : end;
+        }
+        const end = text.indexOf('*/', start + 2);
+        return end === -1 ? text.length : end + 2;
+    }
 
+    private skipStringLiteral(text: string, start: number): number {
+        const quote = text[start];
+        let i = start + 1;
+        while (i < text.length && text[i] !== quote) {
+            // Raw strings have no escapes; interpreted strings and runes cannot span lines
+            if (quote !== '`' && text[i] === '\\') {
+                i++;
+            } else if (quote !== '`' && text[i] === '\n') {
+                return i;
+            }
+            i++;
+        }
+        return i + 1;
+    }
+
+    /**
+     * References to the symbol at the location, including its declaration. Not cached, since
+     * refactorings need them up to date; empty when gopls fails.
+     */
+    async getReferences(location: vscode.Location): Promise<vscode.Location[]> {
+        return this.getReferencesFromGopls(location.uri, location.range.start);
+    }
+
+    /**
+     * Implementations of the interface or method at the location, not cached for the same reason.
+     */
+    async getImplementations(location: vscode.Location): Promise<vscode.Location[]> {
+        return this.getImplementationsFromGopls(location.uri, location.range.start);
+    }
 
     async getSymbolName(location: vscode.Location): Promise<string | undefined> {
+        return this.getCachedName(location, 'symbol', () => this.resolveSymbolName(location));
+    }
+
+    async getInterfaceAndMethodName(location: vscode.Location): Promise<string | undefined> {
+        return this.getCachedName(location, 'interfaceMethod', () => this.resolveInterfaceAndMethodName(location));
+    }
+
+    private async getDocumentSymbols(uri: vscode.Uri): Promise<vscode.DocumentSymbol[] | undefined> {
+        const cached = this.symbolCache.get(uri.fsPath);
+        if (cached) {
+            this.stats.symbolHits++;
+            return cached;
+        }
+        
+        this.stats.symbolMisses++;
+        const symbols = await this.goplsRequest<vscode.DocumentSymbol[]>('documentSymbol', 'vscode.executeDocumentSymbolProvider', uri);
+        
+        // Empty results usually mean gopls is still loading, so ask again next time
+        if (symbols && symbols.length > 0) {
+            this.symbolCache.set(uri.fsPath, symbols);
+        }
+        return symbols;
+    }
+
+    private addNameDependency(location: vscode.Location, document: vscode.TextDocument) {
+        const filePath = location.uri.fsPath;
+        if (filePath === document.uri.fsPath) {
+            return;
+        }
+        let dependents = this.nameDependents.get(filePath);
+        if (!dependents) {
+            dependents = new Set();
+            this.nameDependents.set(filePath, dependents);
+        }
+        dependents.add(document.uri.fsPath);
+    }
+
+    private async getCachedName(location: vscode.Location, kind: string, resolve: () => Promise<string | undefined>): Promise<string | undefined> {
+        const filePath = location.uri.fsPath;
+        const key = `${kind}:${location.range.start.line}:${location.range.start.character}`;
+        let names = this.nameCache.get(filePath);
+        if (names && names.has(key)) {
+            this.stats.nameHits++;
+            return names.get(key);
+        }
+        
+        this.stats.nameMisses++;
+        const name = await resolve();
+        // Misses usually mean gopls is still loading, so ask again next time
+        if (name === undefined) {
+            return undefined;
+        }
+        if (!names) {
+            names = new Map();
+            this.nameCache.set(filePath, names);
+        }
+        names.set(key, name);
+        return name;
+    }
+
+    private async resolveSymbolName(location: vscode.Location): Promise<string | undefined> {
         try {
             // Use VS Code's document symbol provider to get actual symbol information
-            const symbols = await vscode.commands.executeCommand<vscode.DocumentSymbol[]>(
-                'vscode.executeDocumentSymbolProvider',
-                location.uri
-            );
+            const symbols = await this.getDocumentSymbols(location.uri);
             
             if (!symbols) {
                 return this.getSymbolNameFallback(location);
@@ -274,17 +926,15 @@ export class GoAnalyzer {
             return this.getSymbolNameFallback(location);
             
         } catch (error) {
+            getLog().debug(`Resolving the symbol name at ${vscode.workspace.asRelativePath(location.uri)}:${location.range.start.line + 1} failed: ${error}`);
             return this.getSymbolNameFallback(location);
         }
     }
 
-    async getInterfaceAndMethodName(location: vscode.Location): Promise<string | undefined> {
+    private async resolveInterfaceAndMethodName(location: vscode.Location): Promise<string | undefined> {
         try {
             // Use VS Code's document symbol provider to get actual symbol information
-            const symbols = await vscode.commands.executeCommand<vscode.DocumentSymbol[]>(
-                'vscode.executeDocumentSymbolProvider',
-                location.uri
-            );
+            const symbols = await this.getDocumentSymbols(location.uri);
             
             if (!symbols) {
                 return this.getSymbolName(location); // Fall back to just method name
@@ -308,6 +958,7 @@ export class GoAnalyzer {
             return methodSymbol.name;
             
         } catch (error) {
+            getLog().debug(`Resolving the interface method name at ${vscode.workspace.asRelativePath(location.uri)}:${location.range.start.line + 1} failed: ${error}`);
             return this.getSymbolName(location); // Fall back to just method name
         }
     }
@@ -404,6 +1055,7 @@ export class GoAnalyzer {
             match = text.match(/\b(\w+)\b/);
             return match ? match[1] : undefined;
         } catch (error) {
+            getLog().debug(`Reading ${vscode.workspace.asRelativePath(location.uri)} for a symbol name failed: ${error}`);
             return undefined;
         }
     }
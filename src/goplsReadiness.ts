@@ -0,0 +1,81 @@
+import * as vscode from 'vscode';
+
+// Retry delays while gopls is still loading the workspace; the last one repeats
+const RETRY_DELAYS_MS = [250, 500, 1000, 2000, 4000];
+// About two minutes of polling, after which gopls is assumed to be stuck or missing
+const MAX_ATTEMPTS = 35;
+
+export type GoplsReadiness = 'ready' | 'timedOut' | 'cancelled';
+
+/**
+ * Resolves once gopls answers document symbol requests for a Go file, which is when
+ * implementation and reference queries start returning results. Waits for a Go file
+ * to be opened if there is none, then gives up after MAX_ATTEMPTS polls. Never rejects;
+ * cancelling stops the polling.
+ */
+export async function waitForGopls(token: vscode.CancellationToken): Promise<GoplsReadiness> {
+    for (let attempt = 0; attempt < MAX_ATTEMPTS; attempt++) {
+        const document = await findGoDocument(token);
+        if (!document || token.isCancellationRequested) {
+            return 'cancelled';
+        }
+
+        try {
+            const symbols = await vscode.commands.executeCommand<vscode.DocumentSymbol[]>(
+                'vscode.executeDocumentSymbolProvider',
+                document.uri
+            );
+            // While loading, gopls answers with no symbols; that only means ready for files without declarations
+            if (symbols && (symbols.length > 0 || !/^(?:func|type|var|const)\b/m.test(document.getText()))) {
+                return 'ready';
+            }
+        } catch (error) {
+            // The language server is not up yet
+        }
+
+        await delay(RETRY_DELAYS_MS[Math.min(attempt, RETRY_DELAYS_MS.length - 1)], token);
+    }
+    return token.isCancellationRequested ? 'cancelled' : 'timedOut';
+}
+
+async function findGoDocument(token: vscode.CancellationToken): Promise<vscode.TextDocument | undefined> {
+    const isGo = (document: vscode.TextDocument) => document.languageId === 'go' && document.uri.scheme === 'file';
+    const active = vscode.window.activeTextEditor?.document;
+    if (active && isGo(active)) {
+        return active;
+    }
+    const open = vscode.workspace.textDocuments.find(isGo);
+    if (open) {
+        return open;
+    }
+
+    return new Promise(resolve => {
+        const disposables: vscode.Disposable[] = [];
+        const finish = (document: vscode.TextDocument | undefined) => {
+            disposables.forEach(disposable => disposable.dispose());
+            resolve(document);
+        };
+        disposables.push(
+            vscode.workspace.onDidOpenTextDocument(document => {
+                if (isGo(document)) {
+                    finish(document);
+                }
+            }),
+            token.onCancellationRequested(() => finish(undefined))
+        );
+    });
+}
+
+function delay(ms: number, token: vscode.CancellationToken): Promise<void> {
+    return new Promise(resolve => {
+        const timer = setTimeout(() => {
+            listener.dispose();
+            resolve();
+        }, ms);
+        const listener = token.onCancellationRequested(() => {
+            clearTimeout(timer);
+            listener.dispose();
+            resolve();
+        });
+    });
+}
@@ -1,21 +1,72 @@
 import * as vscode from 'vscode';
+import * as path from 'path';
 import { GoAnalyzer } from './goAnalyzer';
+import { getShownImplementations } from './implementationCategory';
+import { isSingular } from './l10n';
+
+export type GutterKind = 'interface' | 'interfaceMethod' | 'implementingType' | 'implementingMethod' | 'both';
+
+const GUTTER_ICONS: Record<GutterKind, string> = {
+    interface: 'resources/emojione--down-arrow.svg',
+    interfaceMethod: 'resources/gutter-interface-method.svg',
+    implementingType: 'resources/emojione--up-arrow.svg',
+    implementingMethod: 'resources/gutter-implementing-method.svg',
+    both: 'resources/gutter-both.svg'
+};
+
+// Interfaces point down to implementations, implementers point up; the ruler marks each side in its own color
+const RULER_GROUPS: Record<GutterKind, 'interface' | 'implementation'> = {
+    interface: 'interface',
+    interfaceMethod: 'interface',
+    implementingType: 'implementation',
+    implementingMethod: 'implementation',
+    both: 'implementation'
+};
+
+// Longer target lists are cut off in the hover, the full list is one click away
+const MAX_HOVER_TARGETS = 10;
+
+// Lines with a gutter icon, so the line number context menu only offers navigation there
+const GUTTER_LINES_CONTEXT_KEY = 'goImplementationLens.gutterLines';
+
+export interface GutterLineTarget {
+    lineNumber?: number;
+    // A Uri from the line number context menu, a string from hover command links
+    uri?: vscode.Uri | string;
+}
+
+interface GutterEntry {
+    kind: GutterKind;
+    range: vscode.Range;
+    name: string;
+    targets: vscode.Location[];
+    command: vscode.Command;
+}
 
 export class GoInterfaceGutterProvider {
-    private interfaceDecorationType: vscode.TextEditorDecorationType;
-    private typeDecorationType: vscode.TextEditorDecorationType;
+    private decorationTypes: Map<GutterKind, vscode.TextEditorDecorationType> = new Map();
+    private rulerDecorationTypes: Map<'interface' | 'implementation', vscode.TextEditorDecorationType> = new Map();
+    // Keyed by document URI, then by line
+    private entries: Map<string, Map<number, GutterEntry>> = new Map();
 
     constructor(private goAnalyzer: GoAnalyzer, private context: vscode.ExtensionContext) {
-        // Create gutter icons for interfaces and types
-        this.interfaceDecorationType = vscode.window.createTextEditorDecorationType({
-            gutterIconPath: context.asAbsolutePath('resources/emojione--down-arrow.svg'),
-            gutterIconSize: '12px'
-        });
-
-        this.typeDecorationType = vscode.window.createTextEditorDecorationType({
-            gutterIconPath: context.asAbsolutePath('resources/emojione--up-arrow.svg'),
-            gutterIconSize: '12px'
-        });
+        // Create a gutter icon per kind of declaration
+        for (const [kind, iconPath] of Object.entries(GUTTER_ICONS) as [GutterKind, string][]) {
+            this.decorationTypes.set(kind, vscode.window.createTextEditorDecorationType({
+                gutterIconPath: context.asAbsolutePath(iconPath),
+                gutterIconSize: '12px'
+            }));
+        }
+
+        // Overview ruler markers use theme colors, so they can be changed via workbench.colorCustomizations
+        this.rulerDecorationTypes.set('interface', vscode.window.createTextEditorDecorationType({
+            overviewRulerColor: new vscode.ThemeColor('goImplementationLens.interfaceOverviewRuler'),
+            overviewRulerLane: vscode.OverviewRulerLane.Center
+        }));
+        this.rulerDecorationTypes.set('implementation', vscode.window.createTextEditorDecorationType({
+            overviewRulerColor: new vscode.ThemeColor('goImplementationLens.implementationOverviewRuler'),
+            overviewRulerLane: vscode.OverviewRulerLane.Center
+        }));
     }
 
 
@@ -25,31 +76,51 @@ export class GoInterfaceGutterProvider {
         }
 
         const config = vscode.workspace.getConfiguration('goImplementationLens');
-        if (!config.get<boolean>('enable', true) || !config.get<boolean>('showGutterIcons', true)) {
-            editor.setDecorations(this.interfaceDecorationType, []);
-            editor.setDecorations(this.typeDecorationType, []);
+        const showIcons = config.get<boolean>('showGutterIcons', true);
+        const showRulerMarkers = config.get<boolean>('showOverviewRulerMarkers', true);
+        if (!config.get<boolean>('enable', true) || (!showIcons && !showRulerMarkers)) {
+            this.applyEntries(editor, [], showIcons, showRulerMarkers);
             return;
         }
 
         const { interfaces, types, methodImplementations } = await this.goAnalyzer.analyzeDocument(editor.document);
-        const interfaceDecorations: vscode.DecorationOptions[] = [];
-        const typeDecorations: vscode.DecorationOptions[] = [];
+        const uri = editor.document.uri;
+        const entries: GutterEntry[] = [];
 
         // Add decorations for interface methods
         if (config.get<boolean>('showOnInterfaces', true)) {
             for (const interfaceInfo of interfaces) {
                 // Add decoration for interface header if it has implementations
-                if (interfaceInfo.implementations.length > 0) {
-                    interfaceDecorations.push({
-                        range: new vscode.Range(interfaceInfo.range.start, interfaceInfo.range.start)
+                const implementations = getShownImplementations(interfaceInfo, config);
+                if (implementations.length > 0) {
+                    entries.push({
+                        kind: 'interface',
+                        range: interfaceInfo.range,
+                        name: interfaceInfo.name,
+                        targets: implementations,
+                        command: {
+                            title: '',
+                            command: 'goImplementationLens.showImplementations',
+                            arguments: [implementations, interfaceInfo.name, new vscode.Location(uri, interfaceInfo.range.start)]
+                        }
                     });
                 }
-                
+
                 // Add decorations for each method
                 for (const method of interfaceInfo.methods) {
-                    if (method.implementations.length > 0) {
-                        interfaceDecorations.push({
-                            range: new vscode.Range(method.range.start, method.range.start)
+                    const shown = getShownImplementations(method, config);
+                    if (shown.length > 0) {
+                        const name = `${interfaceInfo.name}.${method.name}`;
+                        entries.push({
+                            kind: 'interfaceMethod',
+                            range: method.range,
+                            name: name,
+                            targets: shown,
+                            command: {
+                                title: '',
+                                command: 'goImplementationLens.showImplementations',
+                                arguments: [shown, name, new vscode.Location(uri, method.range.start)]
+                            }
                         });
                     }
                 }
@@ -59,20 +130,21 @@ export class GoInterfaceGutterProvider {
         // Add decorations for types that implement interfaces
         if (config.get<boolean>('showOnTypes', true)) {
             for (const typeInfo of types) {
-                if (typeInfo.implementedInterfaces.length > 0) {
-                    const interfaceNames: string[] = [];
-                    for (const location of typeInfo.implementedInterfaces) {
-                        const name = await this.goAnalyzer.getSymbolName(location);
-                        if (name) {
-                            interfaceNames.push(name);
+                // Types whose interfaces could not be resolved get no icon, like the CodeLens
+                if (typeInfo.implementedInterfaceNames.length > 0) {
+                    // Embedding an interface is one way of implementing it, so such types point both ways
+                    const embedsInterface = typeInfo.embeddedTypes.some(embedded => typeInfo.implementedInterfaceNames.includes(embedded));
+                    entries.push({
+                        kind: embedsInterface ? 'both' : 'implementingType',
+                        range: typeInfo.range,
+                        name: typeInfo.name,
+                        targets: typeInfo.implementedInterfaces,
+                        command: {
+                            title: '',
+                            command: 'goImplementationLens.goToInterfaceDefinitions',
+                            arguments: [typeInfo.implementedInterfaces, typeInfo.name, new vscode.Location(uri, typeInfo.range.start)]
                         }
-                    }
-
-                    if (interfaceNames.length > 0) {
-                        typeDecorations.push({
-                            range: new vscode.Range(typeInfo.range.start, typeInfo.range.start)
-                        });
-                    }
+                    });
                 }
             }
         }
@@ -80,18 +152,147 @@ export class GoInterfaceGutterProvider {
         // Add decorations for method implementations
         for (const methodImpl of methodImplementations) {
             if (methodImpl.interfaceMethod) {
-                typeDecorations.push({
-                    range: new vscode.Range(methodImpl.range.start, methodImpl.range.start)
+                const name = methodImpl.receiverType ? `${methodImpl.receiverType}.${methodImpl.name}` : methodImpl.name;
+                entries.push({
+                    kind: 'implementingMethod',
+                    range: methodImpl.range,
+                    name: name,
+                    targets: methodImpl.interfaceMethods,
+                    command: {
+                        title: '',
+                        command: 'goImplementationLens.goToInterfaceDefinitions',
+                        arguments: [methodImpl.interfaceMethods, name, new vscode.Location(uri, methodImpl.range.start)]
+                    }
+                });
+            }
+        }
+
+        this.applyEntries(editor, entries, showIcons, showRulerMarkers);
+    }
+
+    /**
+     * Runs the navigation of the gutter icon on a line. The line number context menu and hover links
+     * pass the 1-based line number; without one the cursor line of the active editor is used.
+     */
+    async navigateFromLine(target?: GutterLineTarget) {
+        const editor = vscode.window.activeTextEditor;
+        const documentUri = target?.uri ? target.uri.toString() : editor?.document.uri.toString();
+        const line = target?.lineNumber !== undefined ? target.lineNumber - 1 : editor?.selection.active.line;
+        if (!documentUri || line === undefined) {
+            return;
+        }
+
+        const entry = this.entries.get(documentUri)?.get(line);
+        if (!entry) {
+            vscode.window.showInformationMessage(vscode.l10n.t('No implementations or interfaces on this line'));
+            return;
+        }
+
+        await vscode.commands.executeCommand(entry.command.command, ...(entry.command.arguments || []));
+    }
+
+    private applyEntries(editor: vscode.TextEditor, entries: GutterEntry[], showIcons: boolean, showRulerMarkers: boolean) {
+        const entriesByLine = new Map<number, GutterEntry>();
+        for (const entry of entries) {
+            // One icon per line; the first declaration on a line wins
+            if (!entriesByLine.has(entry.range.start.line)) {
+                entriesByLine.set(entry.range.start.line, entry);
+            }
+        }
+        this.entries.set(editor.document.uri.toString(), entriesByLine);
+
+        const decorations = new Map<GutterKind, vscode.DecorationOptions[]>();
+        for (const kind of this.decorationTypes.keys()) {
+            decorations.set(kind, []);
+        }
+        const rulerDecorations = new Map<'interface' | 'implementation', vscode.Range[]>();
+        for (const group of this.rulerDecorationTypes.keys()) {
+            rulerDecorations.set(group, []);
+        }
+        for (const [line, entry] of entriesByLine) {
+            const lineRange = editor.document.lineAt(line).range;
+            if (showIcons) {
+                decorations.get(entry.kind)!.push({
+                    // The hover shows when the pointer is over the declaration line
+                    range: lineRange,
+                    hoverMessage: this.buildHoverMessage(editor.document.uri, entry)
                 });
             }
+            if (showRulerMarkers) {
+                rulerDecorations.get(RULER_GROUPS[entry.kind])!.push(lineRange);
+            }
         }
 
-        editor.setDecorations(this.interfaceDecorationType, interfaceDecorations);
-        editor.setDecorations(this.typeDecorationType, typeDecorations);
+        for (const [kind, decorationType] of this.decorationTypes) {
+            editor.setDecorations(decorationType, decorations.get(kind)!);
+        }
+        for (const [group, decorationType] of this.rulerDecorationTypes) {
+            editor.setDecorations(decorationType, rulerDecorations.get(group)!);
+        }
+
+        if (editor === vscode.window.activeTextEditor) {
+            this.updateLineContext(editor);
+        }
+    }
+
+    /**
+     * Keeps the line number context menu in sync with the icons of the focused editor.
+     */
+    updateLineContext(editor: vscode.TextEditor | undefined) {
+        const lines = editor ? this.entries.get(editor.document.uri.toString()) : undefined;
+        const lineNumbers = lines ? Array.from(lines.keys(), line => line + 1) : [];
+        vscode.commands.executeCommand('setContext', GUTTER_LINES_CONTEXT_KEY, lineNumbers);
+    }
+
+    private buildHoverMessage(uri: vscode.Uri, entry: GutterEntry): vscode.MarkdownString {
+        const count = entry.targets.length;
+        const markdown = new vscode.MarkdownString();
+        markdown.isTrusted = { enabledCommands: ['goImplementationLens.navigateFromGutter'] };
+        markdown.appendMarkdown(`**${escapeMarkdown(getHoverHeading(entry))}**\n\n`);
+
+        for (const target of entry.targets.slice(0, MAX_HOVER_TARGETS)) {
+            const fileName = path.basename(target.uri.fsPath);
+            const line = target.range.start.line + 1;
+            // Line fragments on file links open the target at that position
+            const link = target.uri.with({ fragment: `L${line},${target.range.start.character + 1}` });
+            markdown.appendMarkdown(`- [${escapeMarkdown(`${fileName}:${line}`)}](${link.toString()})\n`);
+        }
+        if (count > MAX_HOVER_TARGETS) {
+            markdown.appendMarkdown(`- ${escapeMarkdown(vscode.l10n.t('and {0} more', count - MAX_HOVER_TARGETS))}\n`);
+        }
+
+        const lineTarget: GutterLineTarget = { lineNumber: entry.range.start.line + 1, uri: uri.toString() };
+        const args = encodeURIComponent(JSON.stringify([lineTarget]));
+        markdown.appendMarkdown(`\n[${escapeMarkdown(vscode.l10n.t('Show all'))}](command:goImplementationLens.navigateFromGutter?${args})`);
+        return markdown;
     }
 
     dispose() {
-        this.interfaceDecorationType.dispose();
-        this.typeDecorationType.dispose();
+        for (const decorationType of this.decorationTypes.values()) {
+            decorationType.dispose();
+        }
+        for (const decorationType of this.rulerDecorationTypes.values()) {
+            decorationType.dispose();
+        }
     }
-}
\ No newline at end of file
+}
+
+function getHoverHeading(entry: GutterEntry): string {
+    const count = entry.targets.length;
+    switch (entry.kind) {
+        case 'interface':
+        case 'interfaceMethod':
+            return isSingular(count)
+                ? vscode.l10n.t('{0} has {1} implementation', entry.name, count)
+                : vscode.l10n.t('{0} has {1} implementations', entry.name, count);
+        case 'implementingType':
+        case 'implementingMethod':
+            return vscode.l10n.t('{0} implements', entry.name);
+        case 'both':
+            return vscode.l10n.t('{0} implements and embeds', entry.name);
+    }
+}
+
+function escapeMarkdown(text: string): string {
+    return text.replace(/[\\`*_{}\[\]()#+\-.!|<>]/g, '\\$&');
+}
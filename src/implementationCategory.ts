@@ -0,0 +1,78 @@
+import * as vscode from 'vscode';
+import * as fs from 'fs';
+
+/**
+ * Where an implementation lives: regular code, a _test.go file, or a mock file.
+ */
+export type ImplementationCategory = 'production' | 'test' | 'mock';
+
+export type ImplementationsByCategory = Record<ImplementationCategory, vscode.Location[]>;
+
+// Headers of the usual mock generators, and our own
+const MOCK_GENERATOR_PATTERN = /^\/\/ Code generated by (?:MockGen|mockery|moq|counterfeiter|Go Implementation Lens)\b/m;
+// "mocks/store.go", "mock_store.go" or "store_mock.go"
+const MOCK_PATH_PATTERN = /(?:^|[\\/])(?:mocks?[\\/]|mock_[^\\/]*\.go$|[^\\/]*_mock\.go$)/i;
+
+// Generator headers come first, so the first few lines of a file are enough
+const HEADER_LINES = 10;
+const HEADER_BYTES = 4096;
+
+/**
+ * Whether a Go file holds generated or hand-written mocks, judged by its path and generator header.
+ */
+export function isMockDocument(document: vscode.TextDocument): boolean {
+    return isMockFile(document.uri, document.getText(new vscode.Range(0, 0, Math.min(document.lineCount, HEADER_LINES), 0)));
+}
+
+function isMockFile(uri: vscode.Uri, head: string): boolean {
+    return MOCK_PATH_PATTERN.test(vscode.workspace.asRelativePath(uri)) || MOCK_GENERATOR_PATTERN.test(head);
+}
+
+/**
+ * Test files take precedence: a mock declared in a _test.go file only exists for the tests.
+ * Only the first HEADER_BYTES of the file are read, without opening it as a document. The
+ * analyzer caches the category per file until the file changes.
+ */
+export async function classifyFile(uri: vscode.Uri): Promise<ImplementationCategory> {
+    if (uri.fsPath.endsWith('_test.go')) {
+        return 'test';
+    }
+    if (MOCK_PATH_PATTERN.test(vscode.workspace.asRelativePath(uri))) {
+        return 'mock';
+    }
+    // Open documents may have unsaved changes to the header
+    const open = vscode.workspace.textDocuments.find(document => document.uri.toString() === uri.toString());
+    if (open) {
+        return isMockDocument(open) ? 'mock' : 'production';
+    }
+    try {
+        const head = (await readHead(uri.fsPath)).split('\n').slice(0, HEADER_LINES).join('\n');
+        return isMockFile(uri, head) ? 'mock' : 'production';
+    } catch (error) {
+        return 'production';
+    }
+}
+
+async function readHead(filePath: string): Promise<string> {
+    const file = await fs.promises.open(filePath, 'r');
+    try {
+        const buffer = Buffer.alloc(HEADER_BYTES);
+        const { bytesRead } = await file.read(buffer, 0, HEADER_BYTES, 0);
+        return buffer.toString('utf8', 0, bytesRead);
+    } finally {
+        await file.close();
+    }
+}
+
+/**
+ * The implementations to count and navigate to, leaving out test and mock ones when
+ * goImplementationLens.hideNonProductionImplementations is set.
+ */
+export function getShownImplementations(
+    info: { implementations: vscode.Location[], implementationsByCategory: ImplementationsByCategory },
+    config: vscode.WorkspaceConfiguration
+): vscode.Location[] {
+    return config.get<boolean>('hideNonProductionImplementations', false)
+        ? info.implementationsByCategory.production
+        : info.implementations;
+}
@@ -0,0 +1,111 @@
+import * as vscode from 'vscode';
+import { GoAnalyzer } from './goAnalyzer';
+import { isSingular } from './l10n';
+import { KindedLensSpec, LensRegistry, LensTitleValues } from './lensRegistry';
+
+export type DisplayMode = 'codelens' | 'inlay' | 'both';
+
+export function getDisplayMode(config: vscode.WorkspaceConfiguration): DisplayMode {
+    return config.get<DisplayMode>('displayMode', 'codelens');
+}
+
+function refsLabel(count: number): string {
+    return isSingular(count) ? vscode.l10n.t('{0} ref', count) : vscode.l10n.t('{0} refs', count);
+}
+
+function implsLabel(count: number): string {
+    return '↓' + (isSingular(count) ? vscode.l10n.t('{0} impl', count) : vscode.l10n.t('{0} impls', count));
+}
+
+/**
+ * Compact hint labels: ↓ points at what implements a symbol, ↑ at what it implements.
+ * Kinds registered by other features fall back to their CodeLens title.
+ */
+function formatHintLabel(kind: string, values: LensTitleValues, fallback: string): string {
+    switch (kind) {
+        case 'references':
+            return refsLabel(Number(values.count));
+        case 'implementations': {
+            // Test and mock implementations are summed up, the tooltip has them apart
+            const others = Number(values.test || 0) + Number(values.mocks || 0);
+            return implsLabel(Number(values.count)) + (others > 0 ? ` +${others}` : '');
+        }
+        case 'combined':
+            return vscode.l10n.t('{0} · {1}', implsLabel(Number(values.implementations)), refsLabel(Number(values.references)));
+        case 'implements':
+            return `↑ ${values.names}`;
+        case 'implementing':
+            return `↑ ${values.name}`;
+        default:
+            return fallback;
+    }
+}
+
+/**
+ * Renders the CodeLens data as inlay hints at the end of each declaration line,
+ * so enabling it does not shift lines the way CodeLens does.
+ */
+export class GoInterfaceInlayHintsProvider implements vscode.InlayHintsProvider {
+    private _onDidChangeInlayHints: vscode.EventEmitter<void> = new vscode.EventEmitter<void>();
+    public readonly onDidChangeInlayHints: vscode.Event<void> = this._onDidChangeInlayHints.event;
+
+    constructor(private goAnalyzer: GoAnalyzer, private registry: LensRegistry) {}
+
+    async provideInlayHints(document: vscode.TextDocument, range: vscode.Range, token: vscode.CancellationToken): Promise<vscode.InlayHint[]> {
+        if (document.languageId !== 'go') {
+            return [];
+        }
+
+        const config = vscode.workspace.getConfiguration('goImplementationLens');
+        if (!config.get<boolean>('enable', true) || getDisplayMode(config) === 'codelens') {
+            return [];
+        }
+
+        const analysis = await this.goAnalyzer.analyzeDocument(document);
+        const specs = await this.registry.provideSpecs({
+            document: document,
+            config: config,
+            analysis: analysis,
+            goAnalyzer: this.goAnalyzer
+        });
+
+        // Declarations with several lenses (refs and implementations) get a single hint per line
+        const specsByLine = new Map<number, KindedLensSpec[]>();
+        for (const spec of specs) {
+            const line = spec.range.start.line;
+            if (line < range.start.line || line > range.end.line) {
+                continue;
+            }
+            if (!specsByLine.has(line)) {
+                specsByLine.set(line, []);
+            }
+            specsByLine.get(line)!.push(spec);
+        }
+
+        const hints: vscode.InlayHint[] = [];
+        for (const [line, lineSpecs] of specsByLine) {
+            const parts: vscode.InlayHintLabelPart[] = [];
+            lineSpecs.forEach((spec, index) => {
+                if (index > 0) {
+                    parts.push(new vscode.InlayHintLabelPart('  '));
+                }
+                const part = new vscode.InlayHintLabelPart(formatHintLabel(spec.kind, spec.values, spec.command.title));
+                part.tooltip = spec.command.title;
+                if (spec.command.command) {
+                    part.command = spec.command;
+                }
+                parts.push(part);
+            });
+
+            const hint = new vscode.InlayHint(document.lineAt(line).range.end, parts);
+            hint.paddingLeft = true;
+            hints.push(hint);
+        }
+
+        return hints;
+    }
+
+    refresh(): void {
+        this._onDidChangeInlayHints.fire();
+    }
+}
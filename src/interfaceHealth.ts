@@ -0,0 +1,474 @@
+import * as vscode from 'vscode';
+import * as path from 'path';
+import { AnalysisResult, GoAnalyzer, InterfaceInfo, MethodInfo } from './goAnalyzer';
+import { isSingular } from './l10n';
+import { getLog } from './logger';
+import { RefreshEvent } from './refreshScheduler';
+
+export type HealthIssueKind = 'unusedInterfaceMethod' | 'orphanInterface' | 'deadImplementation';
+
+export interface HealthIssue {
+    kind: HealthIssueKind;
+    uri: vscode.Uri;
+    // The declared name, where diagnostics are shown
+    range: vscode.Range;
+    interfaceName: string;
+    memberName?: string;
+    message: string;
+    // Short reason for the sidebar
+    summary: string;
+}
+
+const DIAGNOSTIC_SOURCE = 'Go Implementation Lens';
+
+const SEVERITIES: { [name: string]: vscode.DiagnosticSeverity } = {
+    hint: vscode.DiagnosticSeverity.Hint,
+    information: vscode.DiagnosticSeverity.Information,
+    warning: vscode.DiagnosticSeverity.Warning,
+    error: vscode.DiagnosticSeverity.Error
+};
+
+// "var _ Reader = (*File)(nil)" and its form inside a var block only assert an implementation
+const ASSERTION_PATTERN = /^\s*(?:var\s+)?_\s+[\w.]+\s*=/;
+// The receiver of "func (s *Store) Get()" names the type without using it
+const RECEIVER_PATTERN = /^\s*func\s*\([^)]*\)/;
+
+interface HealthSettings {
+    unusedInterfaceMethods: boolean;
+    orphanInterfaces: boolean;
+    deadImplementations: boolean;
+    severity: vscode.DiagnosticSeverity;
+    scope: 'openFiles' | 'workspace';
+}
+
+function getHealthSettings(): HealthSettings {
+    const config = vscode.workspace.getConfiguration('goImplementationLens');
+    const enabled = config.get<boolean>('enable', true);
+    return {
+        unusedInterfaceMethods: enabled && config.get<boolean>('reportUnusedInterfaceMethods', true),
+        orphanInterfaces: enabled && config.get<boolean>('reportOrphanInterfaces', false),
+        deadImplementations: enabled && config.get<boolean>('reportDeadImplementations', false),
+        severity: SEVERITIES[config.get<string>('interfaceHealthSeverity', 'hint')] ?? vscode.DiagnosticSeverity.Hint,
+        scope: config.get<string>('interfaceHealthScope', 'openFiles') === 'workspace' ? 'workspace' : 'openFiles'
+    };
+}
+
+/**
+ * Flags interface methods that are never called through the interface. gopls reports calls
+ * through implementing types as references of the interface method too, so each reference
+ * is resolved to its definition: only calls through the interface resolve to the interface method.
+ */
+export async function findUnusedInterfaceMethods(document: vscode.TextDocument, analysis: AnalysisResult): Promise<HealthIssue[]> {
+    const issues: HealthIssue[] = [];
+    for (const interfaceInfo of analysis.interfaces) {
+        for (const method of interfaceInfo.methods) {
+            const concreteCalls = await countCallsNotThroughInterface(document, interfaceInfo, method);
+            if (concreteCalls === undefined) {
+                continue;
+            }
+
+            const name = `${interfaceInfo.name}.${method.name}`;
+            const message = concreteCalls === 0
+                ? vscode.l10n.t('Interface method {0} is never used', name)
+                : isSingular(concreteCalls)
+                    ? vscode.l10n.t('Interface method {0} is never called through the interface ({1} use via a concrete type)', name, concreteCalls)
+                    : vscode.l10n.t('Interface method {0} is never called through the interface ({1} uses via concrete types)', name, concreteCalls);
+            issues.push({
+                kind: 'unusedInterfaceMethod',
+                uri: document.uri,
+                range: findNameRange(document, method.range, method.name),
+                interfaceName: interfaceInfo.name,
+                memberName: method.name,
+                message: message,
+                summary: concreteCalls === 0
+                    ? vscode.l10n.t('never used')
+                    : vscode.l10n.t('only used via concrete types')
+            });
+        }
+    }
+    return issues;
+}
+
+/**
+ * Undefined as soon as one reference goes through the interface, otherwise the number of
+ * references that resolve elsewhere.
+ */
+async function countCallsNotThroughInterface(document: vscode.TextDocument, interfaceInfo: InterfaceInfo, method: MethodInfo): Promise<number | undefined> {
+    let concreteCalls = 0;
+    for (const reference of method.references) {
+        // Mentions inside the interface itself, such as embedding declarations, are not uses
+        if (reference.uri.toString() === document.uri.toString() && interfaceInfo.range.contains(reference.range.start)) {
+            continue;
+        }
+
+        const throughInterface = await resolvesToInterfaceMethod(reference, document.uri, method);
+        // Without a definition the reference can't be ruled out, so count it as a use
+        if (throughInterface !== false) {
+            return undefined;
+        }
+        concreteCalls++;
+    }
+    return concreteCalls;
+}
+
+/**
+ * Flags interfaces without any implementation. Interfaces with implementations are in
+ * analysis.interfaces; every interface declaration has a symbolReferences entry.
+ */
+export function findOrphanInterfaces(document: vscode.TextDocument, analysis: AnalysisResult): HealthIssue[] {
+    const issues: HealthIssue[] = [];
+    for (const symbol of analysis.symbolReferences) {
+        if (symbol.kind !== vscode.SymbolKind.Interface ||
+            analysis.interfaces.some(interfaceInfo => interfaceInfo.range.start.line === symbol.range.start.line)) {
+            continue;
+        }
+        // Constraints such as "interface { ~int | ~float64 }" are satisfied by type arguments, not implemented
+        if (/[~|]/.test(document.getText(symbol.range))) {
+            continue;
+        }
+
+        issues.push({
+            kind: 'orphanInterface',
+            uri: document.uri,
+            range: findNameRange(document, symbol.range, symbol.name),
+            interfaceName: symbol.name,
+            message: vscode.l10n.t('Interface {0} has no implementations', symbol.name),
+            summary: vscode.l10n.t('no implementations')
+        });
+    }
+    return issues;
+}
+
+/**
+ * Flags exported types that are never used and only implement interfaces of this workspace that are
+ * never used either. Compile-time assertions don't count as uses of the type or the interfaces.
+ * Interfaces outside the workspace, such as fmt.Stringer, are used implicitly and keep a type alive.
+ */
+export async function findDeadImplementations(document: vscode.TextDocument, analysis: AnalysisResult): Promise<HealthIssue[]> {
+    const issues: HealthIssue[] = [];
+    // Several types often implement the same interface, so each one is looked up once
+    const interfaceUsed = new Map<string, Promise<boolean>>();
+    const isInterfaceUsed = (location: vscode.Location) => {
+        const key = `${location.uri.toString()}:${location.range.start.line}:${location.range.start.character}`;
+        if (!interfaceUsed.has(key)) {
+            interfaceUsed.set(key, isUsed(location));
+        }
+        return interfaceUsed.get(key)!;
+    };
+
+    for (const typeInfo of analysis.types) {
+        if (!/^[A-Z]/.test(typeInfo.name) || typeInfo.implementedInterfaces.length === 0) {
+            continue;
+        }
+
+        const typeSymbol = analysis.symbolReferences.find(symbol =>
+            symbol.name === typeInfo.name && symbol.range.start.line === typeInfo.range.start.line);
+        if (typeSymbol && await hasUses(typeSymbol.references, new vscode.Location(document.uri, typeInfo.range))) {
+            continue;
+        }
+
+        let allUnused = true;
+        for (const location of typeInfo.implementedInterfaces) {
+            if (!vscode.workspace.getWorkspaceFolder(location.uri) || await isInterfaceUsed(location)) {
+                allUnused = false;
+                break;
+            }
+        }
+        if (!allUnused) {
+            continue;
+        }
+
+        const interfaceNames = typeInfo.implementedInterfaceNames.join(', ');
+        issues.push({
+            kind: 'deadImplementation',
+            uri: document.uri,
+            range: findNameRange(document, typeInfo.range, typeInfo.name),
+            interfaceName: interfaceNames,
+            memberName: typeInfo.name,
+            message: isSingular(typeInfo.implementedInterfaces.length)
+                ? vscode.l10n.t('Type {0} is never used and only implements {1}, which is never used', typeInfo.name, interfaceNames)
+                : vscode.l10n.t('Type {0} is never used and only implements {1}, which are never used', typeInfo.name, interfaceNames),
+            summary: vscode.l10n.t('only implements unused interfaces')
+        });
+    }
+    return issues;
+}
+
+async function isUsed(declaration: vscode.Location): Promise<boolean> {
+    let references: vscode.Location[] | undefined;
+    try {
+        references = await vscode.commands.executeCommand<vscode.Location[]>(
+            'vscode.executeReferenceProvider',
+            declaration.uri,
+            declaration.range.start
+        );
+    } catch (error) {
+        getLog().debug(`Finding references of ${vscode.workspace.asRelativePath(declaration.uri)}:${declaration.range.start.line + 1} failed: ${error}`);
+        // Without references the interface can't be ruled out, so count it as used
+        return true;
+    }
+
+    return hasUses(references || [], declaration);
+}
+
+/**
+ * Whether any reference uses the declared symbol. The declaration itself, including references
+ * from inside it, compile-time assertions and method receivers don't count.
+ */
+async function hasUses(references: vscode.Location[], declaration: vscode.Location): Promise<boolean> {
+    for (const reference of references) {
+        if (reference.uri.toString() === declaration.uri.toString() && declaration.range.contains(reference.range.start)) {
+            continue;
+        }
+        const document = await vscode.workspace.openTextDocument(reference.uri);
+        const line = document.lineAt(reference.range.start.line).text;
+        const receiver = line.match(RECEIVER_PATTERN);
+        if (ASSERTION_PATTERN.test(line) || (receiver && reference.range.start.character < receiver[0].length)) {
+            continue;
+        }
+        return true;
+    }
+    return false;
+}
+
+/**
+ * Whether a reference reported for an interface method resolves to that method, which makes it a
+ * call through the interface. Undefined when the definition can't be resolved.
+ */
+export async function resolvesToInterfaceMethod(reference: vscode.Location, interfaceUri: vscode.Uri, method: MethodInfo): Promise<boolean | undefined> {
+    let definitions: (vscode.Location | vscode.LocationLink)[] | undefined;
+    try {
+        definitions = await vscode.commands.executeCommand<(vscode.Location | vscode.LocationLink)[]>(
+            'vscode.executeDefinitionProvider',
+            reference.uri,
+            reference.range.start
+        );
+    } catch (error) {
+        getLog().debug(`Resolving the definition at ${vscode.workspace.asRelativePath(reference.uri)}:${reference.range.start.line + 1} failed: ${error}`);
+        return undefined;
+    }
+
+    return (definitions || []).some(definition => {
+        const uri = 'targetUri' in definition ? definition.targetUri : definition.uri;
+        const range = 'targetUri' in definition ? (definition.targetSelectionRange || definition.targetRange) : definition.range;
+        return uri.toString() === interfaceUri.toString() && method.range.contains(range.start);
+    });
+}
+
+function findNameRange(document: vscode.TextDocument, range: vscode.Range, name: string): vscode.Range {
+    const line = document.lineAt(range.start.line);
+    const index = line.text.indexOf(name, line.text.startsWith('type ') ? 5 : 0);
+    if (index === -1) {
+        return line.range;
+    }
+    return new vscode.Range(range.start.line, index, range.start.line, index + name.length);
+}
+
+interface DocumentHealth {
+    // The analysis and settings the issues were computed from, so unchanged documents are not checked again
+    interfaces: InterfaceInfo[];
+    settings: string;
+    issues: HealthIssue[];
+}
+
+/**
+ * Checks Go documents for interface health issues, reports them as diagnostics and
+ * lists them in the "Interface Health" sidebar section.
+ */
+export class InterfaceHealthProvider implements vscode.TreeDataProvider<HealthTreeItem>, vscode.Disposable {
+    private _onDidChangeTreeData: vscode.EventEmitter<HealthTreeItem | undefined | null | void> = new vscode.EventEmitter<HealthTreeItem | undefined | null | void>();
+    readonly onDidChangeTreeData: vscode.Event<HealthTreeItem | undefined | null | void> = this._onDidChangeTreeData.event;
+
+    private diagnostics = vscode.languages.createDiagnosticCollection('goImplementationLens');
+    private health: Map<string, DocumentHealth> = new Map();
+    private scanning = false;
+    private rescan = false;
+    // Every Go file of the workspace was checked, so refreshes only need to check what they re-analyzed
+    private scanned = false;
+    private disposables: vscode.Disposable[] = [];
+
+    constructor(private goAnalyzer: GoAnalyzer) {
+        this.disposables.push(
+            this.diagnostics,
+            vscode.window.onDidChangeVisibleTextEditors(() => this.refresh()),
+            vscode.workspace.onDidCloseTextDocument(document => {
+                // Files checked for the workspace scope are closed by VS Code once no editor shows them
+                if (getHealthSettings().scope === 'openFiles') {
+                    this.remove(document.uri);
+                }
+            })
+        );
+        // Files deleted outside the editor never show up in a refresh
+        const watcher = vscode.workspace.createFileSystemWatcher('**/*.go', true, true, false);
+        this.disposables.push(watcher, watcher.onDidDelete(uri => this.remove(uri)));
+    }
+
+    /**
+     * Checks the visible editors, or every Go file of the workspace once when the scope is "workspace".
+     * After that scan, refreshes only check the documents they re-analyzed, unless they refreshed everything.
+     */
+    async refresh(event?: RefreshEvent) {
+        if (getHealthSettings().scope === 'openFiles') {
+            this.scanned = false;
+            const open = new Set(vscode.workspace.textDocuments.map(document => document.uri.toString()));
+            for (const key of [...this.health.keys()]) {
+                if (!open.has(key)) {
+                    this.remove(vscode.Uri.parse(key));
+                }
+            }
+            for (const editor of vscode.window.visibleTextEditors) {
+                this.update(editor.document);
+            }
+            return;
+        }
+
+        if (!event?.all && (this.scanned || this.scanning)) {
+            try {
+                for (const uri of event?.documents || []) {
+                    await this.update(await vscode.workspace.openTextDocument(vscode.Uri.parse(uri)));
+                }
+            } catch (error) {
+                getLog().debug(`Checking interface health of edited files failed: ${error}`);
+            }
+            return;
+        }
+
+        // A scan is already running; run once more when it's done instead of overlapping
+        if (this.scanning) {
+            this.rescan = true;
+            return;
+        }
+        this.scanning = true;
+        try {
+            do {
+                this.rescan = false;
+                const files = await vscode.workspace.findFiles('**/*.go', '**/{vendor,node_modules}/**');
+                for (const file of files) {
+                    await this.update(await vscode.workspace.openTextDocument(file));
+                }
+            } while (this.rescan);
+            this.scanned = true;
+        } catch (error) {
+            getLog().debug(`Checking interface health in the workspace failed: ${error}`);
+        } finally {
+            this.scanning = false;
+        }
+    }
+
+    async update(document: vscode.TextDocument) {
+        if (document.languageId !== 'go' || document.uri.scheme !== 'file') {
+            return;
+        }
+
+        const settings = getHealthSettings();
+        if (!settings.unusedInterfaceMethods && !settings.orphanInterfaces && !settings.deadImplementations) {
+            this.remove(document.uri);
+            return;
+        }
+
+        const analysis = await this.goAnalyzer.analyzeDocument(document);
+        const key = document.uri.toString();
+        const settingsKey = JSON.stringify(settings);
+        const previous = this.health.get(key);
+        if (previous && previous.interfaces === analysis.interfaces && previous.settings === settingsKey) {
+            return;
+        }
+
+        // gopls answers with nothing while it is failing, which would make every interface look orphaned
+        const goplsFailing = this.goAnalyzer.getGoplsHealth().failing;
+        const issues: HealthIssue[] = [];
+        if (settings.unusedInterfaceMethods) {
+            issues.push(...await findUnusedInterfaceMethods(document, analysis));
+        }
+        if (settings.orphanInterfaces && !goplsFailing) {
+            issues.push(...findOrphanInterfaces(document, analysis));
+        }
+        if (settings.deadImplementations) {
+            issues.push(...await findDeadImplementations(document, analysis));
+        }
+
+        this.health.set(key, { interfaces: analysis.interfaces, settings: settingsKey, issues: issues });
+        this.diagnostics.set(document.uri, issues.map(issue => {
+            const diagnostic = new vscode.Diagnostic(issue.range, issue.message, settings.severity);
+            diagnostic.source = DIAGNOSTIC_SOURCE;
+            diagnostic.code = issue.kind;
+            // Rendered faded like other unused code, unless raised to a warning or error
+            if (settings.severity === vscode.DiagnosticSeverity.Hint || settings.severity === vscode.DiagnosticSeverity.Information) {
+                diagnostic.tags = [vscode.DiagnosticTag.Unnecessary];
+            }
+            return diagnostic;
+        }));
+        this._onDidChangeTreeData.fire();
+    }
+
+    remove(uri: vscode.Uri) {
+        if (this.health.delete(uri.toString())) {
+            this.diagnostics.delete(uri);
+            this._onDidChangeTreeData.fire();
+        }
+    }
+
+    getTreeItem(element: HealthTreeItem): vscode.TreeItem {
+        return element;
+    }
+
+    getChildren(element?: HealthTreeItem): HealthTreeItem[] {
+        if (element) {
+            return element.issues.map(issue => HealthTreeItem.forIssue(issue));
+        }
+
+        // One node per interface with issues, across all checked documents
+        const items: HealthTreeItem[] = [];
+        for (const { issues } of this.health.values()) {
+            const byInterface = new Map<string, HealthIssue[]>();
+            for (const issue of issues) {
+                if (!byInterface.has(issue.interfaceName)) {
+                    byInterface.set(issue.interfaceName, []);
+                }
+                byInterface.get(issue.interfaceName)!.push(issue);
+            }
+            for (const [interfaceName, interfaceIssues] of byInterface) {
+                items.push(HealthTreeItem.forInterface(interfaceName, interfaceIssues));
+            }
+        }
+        return items.sort((a, b) => String(a.label).localeCompare(String(b.label)));
+    }
+
+    dispose() {
+        this.disposables.forEach(disposable => disposable.dispose());
+    }
+}
+
+const ISSUE_ICONS: { [kind in HealthIssueKind]: string } = {
+    unusedInterfaceMethod: 'symbol-method',
+    orphanInterface: 'symbol-interface',
+    deadImplementation: 'symbol-struct'
+};
+
+export class HealthTreeItem extends vscode.TreeItem {
+    constructor(label: string, collapsibleState: vscode.TreeItemCollapsibleState, public readonly issues: HealthIssue[]) {
+        super(label, collapsibleState);
+    }
+
+    static forInterface(interfaceName: string, issues: HealthIssue[]): HealthTreeItem {
+        const item = new HealthTreeItem(interfaceName, vscode.TreeItemCollapsibleState.Expanded, issues);
+        item.iconPath = new vscode.ThemeIcon('symbol-interface');
+        item.description = path.basename(issues[0].uri.fsPath);
+        item.contextValue = 'healthInterface';
+        return item;
+    }
+
+    static forIssue(issue: HealthIssue): HealthTreeItem {
+        const item = new HealthTreeItem(issue.memberName || issue.interfaceName, vscode.TreeItemCollapsibleState.None, []);
+        item.iconPath = new vscode.ThemeIcon(ISSUE_ICONS[issue.kind]);
+        item.description = issue.summary;
+        item.tooltip = issue.message;
+        item.contextValue = 'healthIssue';
+        item.command = {
+            command: 'goImplementationLens.openReference',
+            title: vscode.l10n.t('Open'),
+            arguments: [new vscode.Location(issue.uri, issue.range)]
+        };
+        return item;
+    }
+}
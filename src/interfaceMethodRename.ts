@@ -0,0 +1,54 @@
+import * as vscode from 'vscode';
+import { InterfaceInfo, MethodInfo } from './goAnalyzer';
+import { findIdentifier, MethodTargets } from './interfaceMethodTargets';
+
+const GO_KEYWORDS = new Set([
+    'break', 'case', 'chan', 'const', 'continue', 'default', 'defer', 'else', 'fallthrough', 'for', 'func', 'go', 'goto',
+    'if', 'import', 'interface', 'map', 'package', 'range', 'return', 'select', 'struct', 'switch', 'type', 'var'
+]);
+const IDENTIFIER_PATTERN = /^[\p{L}_][\p{L}\p{Nd}_]*$/u;
+
+/**
+ * Input box validation: undefined when the name is valid for a method of the interface.
+ */
+export function validateMethodName(name: string, interfaceInfo: InterfaceInfo, method: MethodInfo): string | undefined {
+    if (!IDENTIFIER_PATTERN.test(name) || GO_KEYWORDS.has(name)) {
+        return vscode.l10n.t('"{0}" is not a valid Go identifier', name);
+    }
+    if (name === '_') {
+        return vscode.l10n.t('Interface methods cannot be named "_"');
+    }
+    if (name !== method.name && interfaceInfo.methods.some(other => other.name === name)) {
+        return vscode.l10n.t('{0} already has a method {1}', interfaceInfo.name, name);
+    }
+    return undefined;
+}
+
+/**
+ * Builds one workspace edit renaming the interface method, its implementations and call sites.
+ * Each change needs confirmation, so applying the edit opens the refactor preview.
+ */
+export async function buildInterfaceMethodRename(
+    document: vscode.TextDocument,
+    method: MethodInfo,
+    targets: MethodTargets,
+    newName: string
+): Promise<vscode.WorkspaceEdit> {
+    const edit = new vscode.WorkspaceEdit();
+    const rename = async (location: vscode.Location, label: string) => {
+        const target = location.uri.toString() === document.uri.toString() ? document : await vscode.workspace.openTextDocument(location.uri);
+        const range = findIdentifier(target, location.range.start, method.name);
+        if (range) {
+            edit.replace(location.uri, range, newName, { needsConfirmation: true, label: label });
+        }
+    };
+
+    await rename(new vscode.Location(document.uri, method.range.start), vscode.l10n.t('Interface method'));
+    for (const implementation of targets.implementations) {
+        await rename(implementation, vscode.l10n.t('Implementations'));
+    }
+    for (const callSite of targets.callSites) {
+        await rename(callSite, vscode.l10n.t('Call sites'));
+    }
+    return edit;
+}
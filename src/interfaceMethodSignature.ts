@@ -0,0 +1,275 @@
+import * as vscode from 'vscode';
+import * as path from 'path';
+import { GoAnalyzer, MethodInfo } from './goAnalyzer';
+import { findIdentifier, MethodTargets } from './interfaceMethodTargets';
+import { findPackageQualifiers, formatImportSpec, GoParam, parseImports, parseParameterList, qualifyType } from './mockGenerator';
+
+export type ParameterPosition = 'first' | 'last';
+
+export interface NewParameter {
+    name: string;
+    type: string;
+    position: ParameterPosition;
+    // Passed at call sites, which are left unchanged without one
+    defaultArgument?: string;
+}
+
+export interface SignatureChange {
+    edit: vscode.WorkspaceEdit;
+    // References that don't call the method, e.g. method values, which can't take the argument
+    skippedReferences: vscode.Location[];
+}
+
+const TYPE_KEYWORDS = new Set(['chan', 'func', 'map', 'struct', 'interface']);
+const NUMERIC_TYPES = new Set([
+    'int', 'int8', 'int16', 'int32', 'int64', 'uint', 'uint8', 'uint16', 'uint32', 'uint64', 'uintptr',
+    'float32', 'float64', 'complex64', 'complex128', 'byte', 'rune'
+]);
+// Standard library packages whose import path is their name, importable without the interface's file importing them
+const STANDARD_PACKAGES = new Set([
+    'bufio', 'bytes', 'cmp', 'context', 'errors', 'expvar', 'flag', 'fmt', 'io', 'iter', 'log', 'maps', 'math', 'mime',
+    'net', 'os', 'path', 'reflect', 'regexp', 'runtime', 'slices', 'sort', 'strconv', 'strings', 'sync', 'syscall',
+    'testing', 'time', 'unicode', 'unique', 'unsafe'
+]);
+
+/**
+ * Parses a parameter such as "ctx context.Context". Variadic parameters aren't supported, since
+ * they can only come last and call sites would need every argument rewritten.
+ */
+export function parseNewParameter(text: string): { name: string, type: string } | undefined {
+    const match = text.trim().match(/^([\p{L}_][\p{L}\p{Nd}_]*)\s+(\S.*)$/u);
+    if (!match || TYPE_KEYWORDS.has(match[1]) || match[2].startsWith('...')) {
+        return undefined;
+    }
+    return { name: match[1], type: match[2].trim() };
+}
+
+/**
+ * Input box validation against the current parameters of the interface method.
+ */
+export function validateNewParameter(text: string, params: GoParam[]): string | undefined {
+    const parameter = parseNewParameter(text);
+    if (!parameter) {
+        return vscode.l10n.t('Enter a name and a type, e.g. "ctx context.Context"');
+    }
+    if (params.some(param => param.name === parameter.name)) {
+        return vscode.l10n.t('There already is a parameter named {0}', parameter.name);
+    }
+    return undefined;
+}
+
+/**
+ * The configured argument for the type from goImplementationLens.defaultArguments, falling back
+ * to its zero value where that is known from the type alone.
+ */
+export function getDefaultArgument(type: string, configured: Record<string, string>): string {
+    if (configured[type] !== undefined) {
+        return configured[type];
+    }
+    if (/^(?:\*|\[\]|map\[|chan\b|<-|func\b|interface\b)/.test(type) || type === 'error' || type === 'any') {
+        return 'nil';
+    }
+    if (type === 'string') {
+        return '""';
+    }
+    if (type === 'bool') {
+        return 'false';
+    }
+    return NUMERIC_TYPES.has(type) ? '0' : '';
+}
+
+/**
+ * Builds one workspace edit adding the parameter to the interface method and every implementation,
+ * and the default argument to every call, importing the packages they need. Both are written as in
+ * the interface's file and qualified with its package name in files of other packages. Each change
+ * needs confirmation, so applying the edit opens the refactor preview.
+ */
+export async function buildSignatureChange(
+    goAnalyzer: GoAnalyzer,
+    document: vscode.TextDocument,
+    method: MethodInfo,
+    targets: MethodTargets,
+    parameter: NewParameter
+): Promise<SignatureChange> {
+    const change: SignatureChange = { edit: new vscode.WorkspaceEdit(), skippedReferences: [] };
+    const documents = new Map<string, vscode.TextDocument>([[document.uri.toString(), document]]);
+    const open = async (uri: vscode.Uri) => {
+        if (!documents.has(uri.toString())) {
+            documents.set(uri.toString(), await vscode.workspace.openTextDocument(uri));
+        }
+        return documents.get(uri.toString())!;
+    };
+
+    const interfaceText = document.getText();
+    const sourcePackage = getPackageClause(interfaceText) || 'main';
+    const interfaceImports = parseImports(interfaceText);
+    let sourceImport: string | undefined;
+    // External test packages share the directory but import the package like any other
+    const inSourcePackage = (target: vscode.TextDocument) =>
+        path.resolve(path.dirname(target.uri.fsPath)) === path.resolve(path.dirname(document.uri.fsPath))
+        && getPackageClause(target.getText()) === sourcePackage;
+
+    const importsByFile = new Map<string, Map<string, string>>();
+    // Adapts the type or argument to the target file and records the imports it needs there
+    const adapt = async (target: vscode.TextDocument, code: string, isType: boolean): Promise<string> => {
+        const local = inSourcePackage(target);
+        const adapted = local ? code : isType ? qualifyType(code, sourcePackage) : qualifyExpression(code, sourcePackage);
+        if (adapted !== code && sourcePackage === 'main') {
+            throw new Error(vscode.l10n.t('{0} refers to package main, which {1} cannot import', code, vscode.workspace.asRelativePath(target.uri)));
+        }
+        for (const name of findPackageQualifiers(adapted)) {
+            let spec = interfaceImports.get(name) || (STANDARD_PACKAGES.has(name) ? `"${name}"` : undefined);
+            if (name === sourcePackage && !local) {
+                sourceImport = sourceImport || formatImportSpec(sourcePackage, await goAnalyzer.getPackagePath(document.uri));
+                spec = sourceImport;
+            }
+            if (!spec) {
+                // In arguments, unknown names are taken for variables in scope at the call
+                if (isType) {
+                    throw new Error(vscode.l10n.t('Cannot tell which package "{0}" refers to. Import it in {1} first', name, vscode.workspace.asRelativePath(document.uri)));
+                }
+                continue;
+            }
+            if (!importsByFile.has(target.uri.toString())) {
+                importsByFile.set(target.uri.toString(), new Map());
+            }
+            importsByFile.get(target.uri.toString())!.set(name, spec);
+        }
+        return adapted;
+    };
+
+    const addParameter = async (location: vscode.Location, label: string) => {
+        const target = await open(location.uri);
+        const list = findCallList(target, location, method.name);
+        if (!list) {
+            return;
+        }
+        // Lists are either all named or all unnamed
+        const params = parseParameterList(target.getText().substring(list.open + 1, list.close));
+        const unnamed = params.length > 0 && params.every(param => !param.name);
+        // Implementations may use the name already, and don't use the new parameter yet
+        const name = params.some(param => param.name === parameter.name) ? '_' : parameter.name;
+        const type = await adapt(target, parameter.type, true);
+        insertIntoList(change.edit, target, list, unnamed ? type : `${name} ${type}`, parameter.position, label);
+    };
+
+    await addParameter(new vscode.Location(document.uri, method.range.start), vscode.l10n.t('Interface method'));
+    for (const implementation of targets.implementations) {
+        await addParameter(implementation, vscode.l10n.t('Implementations'));
+    }
+
+    if (parameter.defaultArgument) {
+        for (const callSite of targets.callSites) {
+            const target = await open(callSite.uri);
+            const list = findCallList(target, callSite, method.name);
+            if (!list) {
+                change.skippedReferences.push(callSite);
+                continue;
+            }
+            const argument = await adapt(target, parameter.defaultArgument, false);
+            insertIntoList(change.edit, target, list, argument, parameter.position, vscode.l10n.t('Call sites'));
+        }
+    }
+
+    for (const [uri, imports] of importsByFile) {
+        const target = documents.get(uri)!;
+        const existing = parseImports(target.getText());
+        for (const [name, spec] of imports) {
+            if (!existing.has(name)) {
+                addImport(change.edit, target, spec);
+            }
+        }
+    }
+    return change;
+}
+
+function getPackageClause(text: string): string | undefined {
+    return (text.match(/^package\s+(\w+)/m) || [])[1];
+}
+
+// Like qualifyType, but leaves string and rune literals and the keys of composite literals alone
+function qualifyExpression(expression: string, qualifier: string): string {
+    return expression
+        .split(/("(?:[^"\\]|\\.)*"|`[^`]*`|'(?:[^'\\]|\\.)*')/)
+        .map((part, index) => index % 2 === 1 ? part : part.replace(/(^|[^\w.]|\.\.\.)([A-Z]\w*)\b(?!\s*:)/g, `$1${qualifier}.$2`))
+        .join('');
+}
+
+/**
+ * Offsets of the parentheses following the method name at the location, in a declaration or a
+ * call. Undefined when the name isn't followed by a list, as in method values.
+ */
+function findCallList(document: vscode.TextDocument, location: vscode.Location, name: string): { open: number, close: number } | undefined {
+    const identifier = findIdentifier(document, location.range.start, name);
+    if (!identifier) {
+        return undefined;
+    }
+    const text = document.getText();
+    let open = document.offsetAt(identifier.end);
+    while (text[open] === ' ' || text[open] === '\t') {
+        open++;
+    }
+    if (text[open] !== '(') {
+        return undefined;
+    }
+    const close = findClosingParen(text, open);
+    return close < 0 ? undefined : { open, close };
+}
+
+// Parentheses in string and rune literals and in comments don't count
+function findClosingParen(text: string, open: number): number {
+    let depth = 0;
+    for (let i = open; i < text.length; i++) {
+        const c = text[i];
+        if (c === '"' || c === '\'') {
+            for (i++; i < text.length && text[i] !== c && text[i] !== '\n'; i++) {
+                if (text[i] === '\\') {
+                    i++;
+                }
+            }
+        } else if (c === '`' || (c === '/' && (text[i + 1] === '/' || text[i + 1] === '*'))) {
+            const end = c === '`' ? text.indexOf('`', i + 1) : text.indexOf(text[i + 1] === '/' ? '\n' : '*/', i + 2);
+            if (end < 0) {
+                return -1;
+            }
+            i = c === '/' && text[i + 1] === '*' ? end + 1 : end;
+        } else if (c === '(') {
+            depth++;
+        } else if (c === ')') {
+            depth--;
+            if (depth === 0) {
+                return i;
+            }
+        }
+    }
+    return -1;
+}
+
+// Keeps multi-line lists with a trailing comma valid
+function insertIntoList(edit: vscode.WorkspaceEdit, document: vscode.TextDocument, list: { open: number, close: number }, item: string, position: ParameterPosition, label: string) {
+    const inner = document.getText().substring(list.open + 1, list.close);
+    const metadata = { needsConfirmation: true, label: label };
+    if (inner.trim().length === 0) {
+        edit.insert(document.uri, document.positionAt(list.open + 1), item, metadata);
+    } else if (position === 'first') {
+        const leading = inner.length - inner.trimStart().length;
+        edit.insert(document.uri, document.positionAt(list.open + 1 + leading), `${item}, `, metadata);
+    } else {
+        const last = list.open + 1 + inner.trimEnd().length;
+        edit.insert(document.uri, document.positionAt(last), inner.trimEnd().endsWith(',') ? ` ${item},` : `, ${item}`, metadata);
+    }
+}
+
+function addImport(edit: vscode.WorkspaceEdit, document: vscode.TextDocument, spec: string) {
+    const text = document.getText();
+    const metadata = { needsConfirmation: true, label: vscode.l10n.t('Imports') };
+    const block = /^import\s*\(/m.exec(text);
+    if (block) {
+        edit.insert(document.uri, document.positionAt(block.index + block[0].length), `\n\t${spec}`, metadata);
+        return;
+    }
+    const packageClause = /^package\s+\w+.*$/m.exec(text);
+    if (packageClause) {
+        edit.insert(document.uri, document.positionAt(packageClause.index + packageClause[0].length), `\n\nimport ${spec}`, metadata);
+    }
+}
@@ -0,0 +1,130 @@
+import * as vscode from 'vscode';
+import { GoAnalyzer, InterfaceInfo, MethodInfo } from './goAnalyzer';
+
+export interface InterfaceMethodTarget {
+    // The interface's document
+    document: vscode.TextDocument;
+    interfaceInfo: InterfaceInfo;
+    method: MethodInfo;
+}
+
+/**
+ * Everything a refactoring of an interface method has to edit besides the interface itself.
+ */
+export interface MethodTargets {
+    // Implementing methods inside the workspace
+    implementations: vscode.Location[];
+    // Implementations outside the workspace, e.g. in the module cache, which can't be edited
+    externalImplementations: vscode.Location[];
+    // "Interface.Method" of other interfaces the implementing methods satisfy, which the refactoring breaks
+    otherInterfaceMethods: string[];
+    // Uses through the interface and directly on the implementing types, without the declarations
+    callSites: vscode.Location[];
+}
+
+/**
+ * The interface method at the position, or the one implemented by the method at the position.
+ * The analyzer keeps serving the previous analysis of files edited since the last refresh, whose
+ * positions a refactoring would edit at, so those are analyzed again first.
+ */
+export async function findInterfaceMethodAt(
+    goAnalyzer: GoAnalyzer,
+    document: vscode.TextDocument,
+    position: vscode.Position
+): Promise<InterfaceMethodTarget | undefined> {
+    goAnalyzer.invalidatePendingChanges();
+    let { interfaceInfo, interfaceMethod, methodImplementation } = await goAnalyzer.findSymbolsAt(document, position);
+    if (!interfaceMethod && methodImplementation?.interfaceMethod) {
+        document = await vscode.workspace.openTextDocument(methodImplementation.interfaceMethod.uri);
+        ({ interfaceInfo, interfaceMethod } = await goAnalyzer.findInterfaceAt(methodImplementation.interfaceMethod));
+    }
+    return interfaceInfo && interfaceMethod ? { document, interfaceInfo, method: interfaceMethod } : undefined;
+}
+
+/**
+ * The same interface method in a current analysis, since files may have been edited while the
+ * user answered prompts. Undefined when it no longer exists.
+ */
+export async function refreshInterfaceMethod(goAnalyzer: GoAnalyzer, target: InterfaceMethodTarget): Promise<InterfaceMethodTarget | undefined> {
+    goAnalyzer.invalidatePendingChanges();
+    const { interfaces } = await goAnalyzer.analyzeDocument(target.document);
+    const interfaceInfo = interfaces.find(candidate => candidate.name === target.interfaceInfo.name);
+    const method = interfaceInfo?.methods.find(candidate => candidate.name === target.method.name);
+    return interfaceInfo && method ? { document: target.document, interfaceInfo, method } : undefined;
+}
+
+/**
+ * Collects the implementations of the interface method and every use of it and of them, asking
+ * gopls again rather than trusting the analysis. Undefined when cancelled.
+ */
+export async function findMethodTargets(
+    goAnalyzer: GoAnalyzer,
+    document: vscode.TextDocument,
+    method: MethodInfo,
+    token: vscode.CancellationToken
+): Promise<MethodTargets | undefined> {
+    const targets: MethodTargets = { implementations: [], externalImplementations: [], otherInterfaceMethods: [], callSites: [] };
+    const documents = new Map<string, vscode.TextDocument>([[document.uri.toString(), document]]);
+    // Identifiers are compared by their exact range, since a one-line implementation such as
+    // "func (l *logStore) Get(id string) (Item, error) { return l.next.Get(id) }" calls the method too
+    const identifierKey = async (location: vscode.Location) => {
+        const key = location.uri.toString();
+        if (!documents.has(key)) {
+            documents.set(key, await vscode.workspace.openTextDocument(location.uri));
+        }
+        const range = findIdentifier(documents.get(key)!, location.range.start, method.name) || location.range;
+        return `${key}#${range.start.line}:${range.start.character}`;
+    };
+    const declaration = new vscode.Location(document.uri, method.range.start);
+    const declarations = new Set<string>([await identifierKey(declaration)]);
+
+    const references = await goAnalyzer.getReferences(declaration);
+    for (const implementation of await goAnalyzer.getImplementations(declaration)) {
+        if (token.isCancellationRequested) {
+            return undefined;
+        }
+        if (!vscode.workspace.getWorkspaceFolder(implementation.uri)) {
+            targets.externalImplementations.push(implementation);
+            continue;
+        }
+
+        targets.implementations.push(implementation);
+        declarations.add(await identifierKey(implementation));
+        // Calls on the concrete type don't go through the interface, so gopls lists them separately
+        references.push(...await goAnalyzer.getReferences(implementation));
+
+        // The implementing method stops satisfying every other interface declaring the same method
+        const { methodImplementation } = await goAnalyzer.findInterfaceAt(implementation);
+        for (const other of methodImplementation?.interfaceMethods || []) {
+            if (other.uri.toString() === document.uri.toString() && method.range.contains(other.range.start)) {
+                continue;
+            }
+            const name = await goAnalyzer.getInterfaceAndMethodName(other);
+            if (name && !targets.otherInterfaceMethods.includes(name)) {
+                targets.otherInterfaceMethods.push(name);
+            }
+        }
+    }
+
+    // Implementations and their references may report the same identifier
+    const seen = new Set<string>(declarations);
+    for (const reference of references) {
+        const key = await identifierKey(reference);
+        if (!seen.has(key)) {
+            seen.add(key);
+            targets.callSites.push(reference);
+        }
+    }
+    return targets;
+}
+
+/**
+ * The range of the identifier at or after the position on its line. gopls points at the
+ * identifier itself, but locations elsewhere on its line are tolerated.
+ */
+export function findIdentifier(document: vscode.TextDocument, position: vscode.Position, name: string): vscode.Range | undefined {
+    const pattern = new RegExp(`(?<![\\p{L}\\p{Nd}_])${name}(?![\\p{L}\\p{Nd}_])`, 'gu');
+    pattern.lastIndex = position.character;
+    const match = pattern.exec(document.lineAt(position.line).text);
+    return match ? new vscode.Range(position.line, match.index, position.line, match.index + name.length) : undefined;
+}
@@ -0,0 +1,227 @@
+import * as vscode from 'vscode';
+import { GoAnalyzer, InterfaceInfo, MethodInfo } from './goAnalyzer';
+import { resolvesToInterfaceMethod } from './interfaceHealth';
+import { isSingular } from './l10n';
+
+// Methods whose consumers overlap at least this much (Jaccard index) are suggested as one interface
+const CLUSTER_SIMILARITY = 0.5;
+
+export interface SegregationConsumer {
+    // Enclosing function of the calls, e.g. "func handleList"
+    name: string;
+    // The first call through the interface
+    location: vscode.Location;
+    methods: Set<string>;
+}
+
+export interface MethodCluster {
+    // Suggested name for the smaller interface
+    name: string;
+    methods: string[];
+    consumerCount: number;
+}
+
+export interface SegregationReport {
+    interfaceName: string;
+    location: vscode.Location;
+    methods: { name: string, signature: string }[];
+    consumers: SegregationConsumer[];
+    clusters: MethodCluster[];
+    // Methods no consumer calls through the interface
+    unusedMethods: string[];
+}
+
+/**
+ * Finds out which methods of the interface each consumer function calls through the interface,
+ * and clusters methods that are used together into suggested smaller interfaces.
+ * Undefined when cancelled.
+ */
+export async function analyzeSegregation(
+    goAnalyzer: GoAnalyzer,
+    document: vscode.TextDocument,
+    interfaceInfo: InterfaceInfo,
+    token: vscode.CancellationToken
+): Promise<SegregationReport | undefined> {
+    // Calls through the interface, grouped by file so enclosing functions are resolved once per file
+    const callsByFile = new Map<string, { method: string, location: vscode.Location }[]>();
+    for (const method of interfaceInfo.methods) {
+        for (const reference of method.references) {
+            if (token.isCancellationRequested) {
+                return undefined;
+            }
+            if (reference.uri.toString() === document.uri.toString() && interfaceInfo.range.contains(reference.range.start)) {
+                continue;
+            }
+            if (await resolvesToInterfaceMethod(reference, document.uri, method) !== true) {
+                continue;
+            }
+
+            const key = reference.uri.toString();
+            if (!callsByFile.has(key)) {
+                callsByFile.set(key, []);
+            }
+            callsByFile.get(key)!.push({ method: method.name, location: reference });
+        }
+    }
+
+    const consumers = new Map<string, SegregationConsumer>();
+    for (const [key, calls] of callsByFile) {
+        const callDocument = await vscode.workspace.openTextDocument(vscode.Uri.parse(key));
+        const names = await goAnalyzer.getEnclosingSymbolNames(callDocument, calls.map(call => call.location.range.start));
+        calls.forEach((call, index) => {
+            const consumerKey = `${key}#${names[index]}`;
+            if (!consumers.has(consumerKey)) {
+                consumers.set(consumerKey, { name: names[index], location: call.location, methods: new Set() });
+            }
+            consumers.get(consumerKey)!.methods.add(call.method);
+        });
+    }
+
+    const methodNames = interfaceInfo.methods.map(method => method.name);
+    const consumerList = [...consumers.values()].sort((a, b) => a.name.localeCompare(b.name));
+    const clusters = clusterMethods(methodNames, consumerList);
+
+    return {
+        interfaceName: interfaceInfo.name,
+        location: new vscode.Location(interfaceInfo.uri, interfaceInfo.range.start),
+        methods: interfaceInfo.methods.map(method => ({
+            name: method.name,
+            signature: getMethodSignature(document, method)
+        })),
+        consumers: consumerList,
+        clusters: clusters.map((methods, index) => ({
+            name: clusters.length > 1 ? `${interfaceInfo.name}${index + 1}` : interfaceInfo.name,
+            methods: methods,
+            consumerCount: consumerList.filter(consumer => methods.some(method => consumer.methods.has(method))).length
+        })),
+        unusedMethods: methodNames.filter(method => !consumerList.some(consumer => consumer.methods.has(method)))
+    };
+}
+
+/**
+ * The method's signature on one line, for the suggested interfaces. Like the mock generator, it reads
+ * the whole range, since signatures may span lines, and drops comments and trailing commas.
+ */
+export function getMethodSignature(document: vscode.TextDocument, method: MethodInfo): string {
+    return document.getText(new vscode.Range(method.range.start, document.lineAt(method.range.end.line).range.end))
+        .replace(/\/\*[\s\S]*?\*\//g, '')
+        .replace(/\/\/.*$/gm, '')
+        .replace(/\s+/g, ' ')
+        .replace(/\(\s+/g, '(')
+        .replace(/,?\s*\)/g, ')')
+        .trim();
+}
+
+/**
+ * Agglomerative clustering of the used methods: starting with one cluster per method, the two
+ * clusters with the most similar consumers are merged until no pair reaches CLUSTER_SIMILARITY.
+ */
+export function clusterMethods(methodNames: string[], consumers: SegregationConsumer[]): string[][] {
+    let clusters = methodNames
+        .map(method => ({
+            methods: [method],
+            consumers: new Set(consumers.filter(consumer => consumer.methods.has(method)))
+        }))
+        .filter(cluster => cluster.consumers.size > 0);
+
+    while (clusters.length > 1) {
+        let best: { i: number, j: number, similarity: number } | undefined;
+        for (let i = 0; i < clusters.length; i++) {
+            for (let j = i + 1; j < clusters.length; j++) {
+                const similarity = jaccard(clusters[i].consumers, clusters[j].consumers);
+                if (!best || similarity > best.similarity) {
+                    best = { i, j, similarity };
+                }
+            }
+        }
+        if (!best || best.similarity < CLUSTER_SIMILARITY) {
+            break;
+        }
+
+        const merged = {
+            methods: [...clusters[best.i].methods, ...clusters[best.j].methods],
+            consumers: new Set([...clusters[best.i].consumers, ...clusters[best.j].consumers])
+        };
+        clusters = clusters.filter((_, index) => index !== best!.i && index !== best!.j);
+        clusters.push(merged);
+    }
+
+    // Keep the declaration order, within and across clusters
+    const order = (method: string) => methodNames.indexOf(method);
+    return clusters
+        .map(cluster => cluster.methods.sort((a, b) => order(a) - order(b)))
+        .sort((a, b) => order(a[0]) - order(b[0]));
+}
+
+function jaccard<T>(a: Set<T>, b: Set<T>): number {
+    let shared = 0;
+    for (const item of a) {
+        if (b.has(item)) {
+            shared++;
+        }
+    }
+    return shared / (a.size + b.size - shared);
+}
+
+export function formatSegregationReport(report: SegregationReport): string {
+    const location = (target: vscode.Location) => `${vscode.workspace.asRelativePath(target.uri)}:${target.range.start.line + 1}`;
+    const lines = [
+        `# ${vscode.l10n.t('Interface segregation: {0}', report.interfaceName)}`,
+        '',
+        `\`${location(report.location)}\` · ` +
+            (isSingular(report.methods.length)
+                ? vscode.l10n.t('{0} method', report.methods.length)
+                : vscode.l10n.t('{0} methods', report.methods.length)) + ' · ' +
+            (isSingular(report.consumers.length)
+                ? vscode.l10n.t('{0} consumer', report.consumers.length)
+                : vscode.l10n.t('{0} consumers', report.consumers.length)),
+        ''
+    ];
+
+    if (report.consumers.length === 0) {
+        lines.push(vscode.l10n.t('No calls through the interface were found.'), '');
+        return lines.join('\n');
+    }
+
+    // One row per consumer, one column per method
+    lines.push(`## ${vscode.l10n.t('Methods used per consumer')}`, '');
+    lines.push(`| ${vscode.l10n.t('Consumer')} | ${vscode.l10n.t('Location')} | ${report.methods.map(method => method.name).join(' | ')} |`);
+    lines.push(`|---|---|${report.methods.map(() => ':-:').join('|')}|`);
+    for (const consumer of report.consumers) {
+        const cells = report.methods.map(method => consumer.methods.has(method.name) ? '✓' : '');
+        lines.push(`| \`${consumer.name}\` | \`${location(consumer.location)}\` | ${cells.join(' | ')} |`);
+    }
+    lines.push('');
+
+    lines.push(`## ${vscode.l10n.t('Suggested split')}`, '');
+    if (report.clusters.length <= 1) {
+        lines.push(vscode.l10n.t('The consumers use the methods together, so there is no split to suggest.'), '');
+    } else {
+        for (const cluster of report.clusters) {
+            lines.push(`- **${cluster.name}**: ${cluster.methods.map(method => `\`${method}\``).join(', ')} (` +
+                (isSingular(cluster.consumerCount)
+                    ? vscode.l10n.t('used by {0} consumer', cluster.consumerCount)
+                    : vscode.l10n.t('used by {0} consumers', cluster.consumerCount)) + ')');
+        }
+        lines.push('', '```go');
+        const signatures = new Map(report.methods.map(method => [method.name, method.signature]));
+        for (const cluster of report.clusters) {
+            lines.push(`type ${cluster.name} interface {`);
+            cluster.methods.forEach(method => lines.push(`\t${signatures.get(method)}`));
+            lines.push('}', '');
+        }
+        // Embedding the parts keeps the original interface for implementations and remaining callers
+        lines.push(`type ${report.interfaceName} interface {`);
+        report.clusters.forEach(cluster => lines.push(`\t${cluster.name}`));
+        report.unusedMethods.forEach(method => lines.push(`\t${signatures.get(method)}`));
+        lines.push('}', '```', '');
+    }
+
+    if (report.unusedMethods.length > 0) {
+        lines.push(`## ${vscode.l10n.t('Methods not called through the interface')}`, '');
+        report.unusedMethods.forEach(method => lines.push(`- \`${method}\``));
+        lines.push('');
+    }
+
+    return lines.join('\n');
+}
@@ -0,0 +1,18 @@
+import * as vscode from 'vscode';
+
+let pluralRules: Intl.PluralRules | undefined;
+
+/**
+ * Whether `count` takes the singular form in the display language. This is not the same
+ * as `count === 1` everywhere; French, for example, also uses the singular for 0.
+ */
+export function isSingular(count: number): boolean {
+    if (!pluralRules) {
+        try {
+            pluralRules = new Intl.PluralRules(vscode.env.language);
+        } catch (error) {
+            pluralRules = new Intl.PluralRules('en');
+        }
+    }
+    return pluralRules.select(count) === 'one';
+}
@@ -0,0 +1,332 @@
+import * as vscode from 'vscode';
+import { AnalysisResult, GoAnalyzer } from './goAnalyzer';
+import { getShownImplementations, ImplementationsByCategory } from './implementationCategory';
+import { isSingular } from './l10n';
+
+/**
+ * Built-in lens kinds. Contributors registered by other features may use their own kind strings.
+ */
+export type LensKind = 'references' | 'implementations' | 'combined' | 'implements' | 'implementing';
+
+export interface LensContext {
+    document: vscode.TextDocument;
+    config: vscode.WorkspaceConfiguration;
+    analysis: AnalysisResult;
+    goAnalyzer: GoAnalyzer;
+}
+
+export interface LensSpec {
+    // Identifies the symbol the lens belongs to, so each (symbol, kind) pair is emitted once
+    symbolId: string;
+    range: vscode.Range;
+    command: vscode.Command;
+    // The values the title was formatted from, so other renderers can build their own labels
+    values: LensTitleValues;
+}
+
+export interface KindedLensSpec extends LensSpec {
+    kind: string;
+}
+
+export interface LensContributor {
+    readonly kind: string;
+    provideLenses(context: LensContext): LensSpec[] | Promise<LensSpec[]>;
+}
+
+export type LensTitleValues = Record<string, string | number>;
+
+type ImplementationsInfo = { implementations: vscode.Location[], implementationsByCategory: ImplementationsByCategory };
+
+// Built-in titles are localized, so they are functions rather than templates
+const DEFAULT_TITLES: Record<LensKind, (values: LensTitleValues) => string> = {
+    references: ({ count }) => isSingular(Number(count))
+        ? vscode.l10n.t('{0} ref', count)
+        : vscode.l10n.t('{0} refs', count),
+    // Production implementations first, with test and mock ones listed separately
+    implementations: ({ count, production = count, test, mocks }) => (isSingular(Number(production))
+        ? vscode.l10n.t('{0} implementation', production)
+        : vscode.l10n.t('{0} implementations', production)) + formatNonProductionSuffix(Number(test || 0), Number(mocks || 0)),
+    combined: ({ implementations, references }) => vscode.l10n.t('{0} · {1}',
+        isSingular(Number(implementations)) ? vscode.l10n.t('{0} impl', implementations) : vscode.l10n.t('{0} impls', implementations),
+        isSingular(Number(references)) ? vscode.l10n.t('{0} ref', references) : vscode.l10n.t('{0} refs', references)),
+    implements: ({ names }) => vscode.l10n.t('Implements: {0}', names),
+    implementing: ({ name }) => vscode.l10n.t('Implementing: {0}', name)
+};
+
+// " (+5 test, +1 mock)" after the production count, empty without test and mock implementations
+function formatNonProductionSuffix(test: number, mocks: number): string {
+    const parts: string[] = [];
+    if (test > 0) {
+        parts.push(vscode.l10n.t('+{0} test', test));
+    }
+    if (mocks > 0) {
+        parts.push(isSingular(mocks) ? vscode.l10n.t('+{0} mock', mocks) : vscode.l10n.t('+{0} mocks', mocks));
+    }
+    return parts.length > 0 ? ` (${parts.join(', ')})` : '';
+}
+
+export function symbolId(range: vscode.Range): string {
+    return `${range.start.line}:${range.start.character}`;
+}
+
+/**
+ * Expands a lens title template such as "{count} impls" from the goImplementationLens.titleTemplates
+ * setting, falling back to the localized built-in title. Every numeric value also gets an
+ * English plural suffix placeholder: {s} for {count}, {<name>S} otherwise.
+ */
+export function formatLensTitle(config: vscode.WorkspaceConfiguration, kind: string, values: LensTitleValues): string {
+    const templates = config.get<Record<string, string>>('titleTemplates', {});
+    const template = templates[kind];
+    if (!template) {
+        const defaultTitle = DEFAULT_TITLES[kind as LensKind];
+        return defaultTitle ? defaultTitle(values) : '';
+    }
+
+    const expanded: Record<string, string> = {};
+    for (const [key, value] of Object.entries(values)) {
+        expanded[key] = String(value);
+        if (typeof value === 'number') {
+            expanded[key === 'count' ? 's' : `${key}S`] = value === 1 ? '' : 's';
+        }
+    }
+
+    return template.replace(/\{(\w+)\}/g, (match, key: string) => expanded[key] !== undefined ? expanded[key] : match);
+}
+
+export class LensRegistry {
+    private contributors: LensContributor[] = [];
+
+    /**
+     * Lenses on the same line are shown in registration order.
+     */
+    register(contributor: LensContributor): vscode.Disposable {
+        this.contributors.push(contributor);
+        return new vscode.Disposable(() => {
+            this.contributors = this.contributors.filter(existing => existing !== contributor);
+        });
+    }
+
+    async provideLenses(context: LensContext): Promise<vscode.CodeLens[]> {
+        const specs = await this.provideSpecs(context);
+        return specs.map(spec => new vscode.CodeLens(spec.range, spec.command));
+    }
+
+    /**
+     * The deduplicated lens data, tagged with its kind, for renderers other than CodeLens.
+     */
+    async provideSpecs(context: LensContext): Promise<KindedLensSpec[]> {
+        const emitted = new Set<string>();
+        const specs: KindedLensSpec[] = [];
+
+        for (const contributor of this.contributors) {
+            for (const spec of await contributor.provideLenses(context)) {
+                const key = `${spec.symbolId}|${contributor.kind}`;
+                if (emitted.has(key)) {
+                    continue;
+                }
+                emitted.add(key);
+                specs.push({ ...spec, kind: contributor.kind });
+            }
+        }
+
+        return specs;
+    }
+}
+
+function isCombined(config: vscode.WorkspaceConfiguration): boolean {
+    // Combined lenses merge refs and implementations on interfaces, so they need both to be shown
+    return config.get<boolean>('showReferences', true) &&
+        config.get<boolean>('showOnInterfaces', true) &&
+        config.get<string>('lensStyle', 'separate') === 'combined';
+}
+
+function countCommand(title: string, count: number, command: string, args: unknown[]): vscode.Command {
+    // No command when there is nothing to navigate to
+    return count > 0 ? { title, command, arguments: args } : { title, command: '' };
+}
+
+export const referencesLensContributor: LensContributor = {
+    kind: 'references',
+    provideLenses({ document, config, analysis }) {
+        if (!config.get<boolean>('showReferences', true)) {
+            return [];
+        }
+
+        const combined = isCombined(config);
+        const specs: LensSpec[] = [];
+
+        // Add code lenses for symbol references (functions, variables, constants, etc.)
+        for (const symbolRef of analysis.symbolReferences) {
+            // Interfaces get a single combined lens instead
+            if (combined && symbolRef.kind === vscode.SymbolKind.Interface &&
+                analysis.interfaces.some(interfaceInfo => interfaceInfo.range.isEqual(symbolRef.range))) {
+                continue;
+            }
+
+            const count = symbolRef.references.length;
+            specs.push({
+                symbolId: symbolId(symbolRef.range),
+                range: symbolRef.range,
+                values: { count },
+                command: countCommand(
+                    formatLensTitle(config, 'references', { count }), count,
+                    'goImplementationLens.showReferences',
+                    [symbolRef.references, symbolRef.name, symbolRef.kind, new vscode.Location(document.uri, symbolRef.range.start)])
+            });
+        }
+
+        // Interface methods are not part of symbolReferences, since interface children are analyzed separately
+        if (config.get<boolean>('showOnInterfaces', true) && !combined) {
+            for (const interfaceInfo of analysis.interfaces) {
+                for (const method of interfaceInfo.methods) {
+                    const count = method.references.length;
+                    specs.push({
+                        symbolId: symbolId(method.range),
+                        range: method.range,
+                        values: { count },
+                        command: countCommand(
+                            formatLensTitle(config, 'references', { count }), count,
+                            'goImplementationLens.showReferences',
+                            [method.references, `${interfaceInfo.name}.${method.name}`, vscode.SymbolKind.Method, new vscode.Location(document.uri, method.range.start)])
+                    });
+                }
+            }
+        }
+
+        return specs;
+    }
+};
+
+export const combinedLensContributor: LensContributor = {
+    kind: 'combined',
+    provideLenses({ document, config, analysis }) {
+        if (!isCombined(config)) {
+            return [];
+        }
+
+        const specs: LensSpec[] = [];
+        const addSpec = (range: vscode.Range, info: ImplementationsInfo, references: vscode.Location[], name: string, kind: vscode.SymbolKind) => {
+            const implementations = getShownImplementations(info, config);
+            const values = { implementations: implementations.length, references: references.length };
+            specs.push({
+                symbolId: symbolId(range),
+                range: range,
+                values: values,
+                command: countCommand(
+                    formatLensTitle(config, 'combined', values),
+                    implementations.length + references.length,
+                    'goImplementationLens.showImplementationsAndReferences',
+                    [implementations, references, name, kind, new vscode.Location(document.uri, range.start)])
+            });
+        };
+
+        for (const interfaceInfo of analysis.interfaces) {
+            addSpec(interfaceInfo.range, interfaceInfo, interfaceInfo.references, interfaceInfo.name, vscode.SymbolKind.Interface);
+            for (const method of interfaceInfo.methods) {
+                addSpec(method.range, method, method.references, `${interfaceInfo.name}.${method.name}`, vscode.SymbolKind.Method);
+            }
+        }
+
+        return specs;
+    }
+};
+
+export const implementationsLensContributor: LensContributor = {
+    kind: 'implementations',
+    provideLenses({ document, config, analysis }) {
+        if (!config.get<boolean>('showOnInterfaces', true) || isCombined(config)) {
+            return [];
+        }
+
+        const specs: LensSpec[] = [];
+        const hideNonProduction = config.get<boolean>('hideNonProductionImplementations', false);
+        const addSpec = (range: vscode.Range, info: ImplementationsInfo, name: string) => {
+            // Always shown, even with 0 implementations
+            const implementations = getShownImplementations(info, config);
+            const { production, test, mock } = info.implementationsByCategory;
+            // {count} stays the total shown, as in templates written before the categories existed
+            const values: LensTitleValues = hideNonProduction
+                ? { count: implementations.length, production: production.length }
+                : { count: implementations.length, production: production.length, test: test.length, mocks: mock.length };
+            specs.push({
+                symbolId: symbolId(range),
+                range: range,
+                values: values,
+                command: countCommand(
+                    formatLensTitle(config, 'implementations', values), implementations.length,
+                    'goImplementationLens.showImplementations',
+                    [implementations, name, new vscode.Location(document.uri, range.start)])
+            });
+        };
+
+        for (const interfaceInfo of analysis.interfaces) {
+            addSpec(interfaceInfo.range, interfaceInfo, interfaceInfo.name);
+            for (const method of interfaceInfo.methods) {
+                addSpec(method.range, method, `${interfaceInfo.name}.${method.name}`);
+            }
+        }
+
+        return specs;
+    }
+};
+
+export const implementsLensContributor: LensContributor = {
+    kind: 'implements',
+    provideLenses({ document, config, analysis }) {
+        if (!config.get<boolean>('showOnTypes', true)) {
+            return [];
+        }
+
+        const specs: LensSpec[] = [];
+        for (const typeInfo of analysis.types) {
+            const interfaceNames = typeInfo.implementedInterfaceNames;
+            if (interfaceNames.length > 0) {
+                const values = { names: interfaceNames.join(', '), count: interfaceNames.length };
+                specs.push({
+                    symbolId: symbolId(typeInfo.range),
+                    range: typeInfo.range,
+                    values: values,
+                    command: {
+                        title: formatLensTitle(config, 'implements', values),
+                        command: 'goImplementationLens.goToInterfaceDefinitions',
+                        arguments: [typeInfo.implementedInterfaces, typeInfo.name, new vscode.Location(document.uri, typeInfo.range.start)]
+                    }
+                });
+            }
+        }
+
+        return specs;
+    }
+};
+
+export const implementingLensContributor: LensContributor = {
+    kind: 'implementing',
+    provideLenses({ config, analysis }) {
+        const specs: LensSpec[] = [];
+        for (const methodImpl of analysis.methodImplementations) {
+            if (!methodImpl.interfaceMethod) {
+                continue;
+            }
+
+            const values = { name: methodImpl.interfaceMethodName || vscode.l10n.t('Interface') };
+            specs.push({
+                symbolId: symbolId(methodImpl.range),
+                range: methodImpl.range,
+                values: values,
+                command: {
+                    title: formatLensTitle(config, 'implementing', values),
+                    command: 'goImplementationLens.goToInterface',
+                    arguments: [
+                        methodImpl.interfaceMethod.uri.toString(),
+                        {
+                            line: methodImpl.interfaceMethod.range.start.line,
+                            character: methodImpl.interfaceMethod.range.start.character
+                        }
+                    ]
+                }
+            });
+        }
+
+        return specs;
+    }
+};
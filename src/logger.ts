@@ -0,0 +1,18 @@
+import * as vscode from 'vscode';
+
+let channel: vscode.LogOutputChannel | undefined;
+
+/**
+ * The extension's output channel. Its log level follows "Developer: Set Log Level...".
+ */
+export function getLog(): vscode.LogOutputChannel {
+    if (!channel) {
+        channel = vscode.window.createOutputChannel('Go Implementation Lens', { log: true });
+    }
+    return channel;
+}
+
+export function disposeLog() {
+    channel?.dispose();
+    channel = undefined;
+}
@@ -0,0 +1,662 @@
+import * as vscode from 'vscode';
+import * as path from 'path';
+import { GoAnalyzer, InterfaceInfo } from './goAnalyzer';
+import { LensContributor, symbolId } from './lensRegistry';
+import { getLog } from './logger';
+import { RefreshEvent } from './refreshScheduler';
+
+export type MockStyle = 'funcFields' | 'moq' | 'gomock';
+
+const MOCK_STYLES: MockStyle[] = ['funcFields', 'moq', 'gomock'];
+const DEFAULT_FILE_PATTERN = 'mocks/mock_{iface}.go';
+const GOMOCK_IMPORT = 'go.uber.org/mock/gomock';
+
+// The first line of every generated mock; regeneration only touches files that start with it
+const HEADER_PATTERN = /^\/\/ Code generated by Go Implementation Lens from (\w+) \((\w+)\); DO NOT EDIT\./;
+
+export interface GoParam {
+    name: string;
+    // Variadic parameters keep their "..." prefix
+    type: string;
+}
+
+export interface GoMethodSignature {
+    name: string;
+    params: GoParam[];
+    results: string[];
+}
+
+/**
+ * Parses an interface method such as "Get(ctx context.Context, id string) (*Item, error)".
+ */
+export function parseMethodSignature(text: string): GoMethodSignature | undefined {
+    const source = text.replace(/\/\*[\s\S]*?\*\//g, '').replace(/\/\/.*$/gm, '').replace(/\s+/g, ' ').trim();
+    const match = source.match(/^(\w+)\s*\(/);
+    if (!match) {
+        return undefined;
+    }
+
+    const open = match[0].length - 1;
+    const close = findClosingBracket(source, open);
+    if (close === -1) {
+        return undefined;
+    }
+
+    let results = source.substring(close + 1).trim();
+    if (results.startsWith('(')) {
+        results = results.substring(1, findClosingBracket(results, 0));
+    }
+
+    return {
+        name: match[1],
+        params: parseParameterList(source.substring(open + 1, close)),
+        results: parseParameterList(results).map(result => result.type)
+    };
+}
+
+function findClosingBracket(text: string, open: number): number {
+    let depth = 0;
+    for (let i = open; i < text.length; i++) {
+        if ('([{'.includes(text[i])) {
+            depth++;
+        } else if (')]}'.includes(text[i])) {
+            depth--;
+            if (depth === 0) {
+                return i;
+            }
+        }
+    }
+    return -1;
+}
+
+function splitTopLevel(text: string): string[] {
+    const parts: string[] = [];
+    let depth = 0;
+    let start = 0;
+    for (let i = 0; i < text.length; i++) {
+        if ('([{'.includes(text[i])) {
+            depth++;
+        } else if (')]}'.includes(text[i])) {
+            depth--;
+        } else if (text[i] === ',' && depth === 0) {
+            parts.push(text.substring(start, i));
+            start = i + 1;
+        }
+    }
+    parts.push(text.substring(start));
+    return parts.map(part => part.trim()).filter(part => part.length > 0);
+}
+
+// Types that start with a keyword followed by a space, so "chan int" isn't read as a parameter named chan
+const TYPE_KEYWORDS = new Set(['chan', 'func', 'map', 'struct', 'interface']);
+
+function splitParameterName(item: string): GoParam | undefined {
+    const match = item.match(/^(\w+)\s+(\S.*)$/);
+    return match && !TYPE_KEYWORDS.has(match[1]) ? { name: match[1], type: match[2] } : undefined;
+}
+
+/**
+ * Parses a parameter or result list. Go lists are either all named, where "a, b int" shares
+ * the type, or all unnamed.
+ */
+export function parseParameterList(text: string): GoParam[] {
+    const items = splitTopLevel(text);
+    if (!items.some(item => splitParameterName(item))) {
+        return items.map(item => ({ name: '', type: item }));
+    }
+
+    const params: GoParam[] = [];
+    let type = '';
+    for (let i = items.length - 1; i >= 0; i--) {
+        const named = splitParameterName(items[i]);
+        if (named) {
+            type = named.type;
+        }
+        params.unshift({ name: named ? named.name : items[i], type: type });
+    }
+    return params;
+}
+
+export interface MockSpec {
+    interfaceName: string;
+    style: MockStyle;
+    // Package of the mock file
+    packageName: string;
+    // Set when the mock lives in another package than the interface
+    source?: { name: string, importPath: string };
+    // Import specs of the interface's file, by package name
+    imports: Map<string, string>;
+    methods: GoMethodSignature[];
+}
+
+// Identifiers the generated method bodies declare themselves
+const RESERVED_NAMES = new Set(['mock', 'mr', 'm', 'ret', 'varargs', 'callInfo', 'calls', 'a']);
+
+/**
+ * Qualifies the exported identifiers in a type from the interface's package with its package name,
+ * for use in another package. Predeclared types are lowercase, so they are left alone.
+ */
+export function qualifyType(type: string, qualifier: string): string {
+    return type.replace(/(^|[^\w.]|\.\.\.)([A-Z]\w*)/g, `$1${qualifier}.$2`);
+}
+
+/**
+ * Names of the packages qualifying identifiers in a type, e.g. "context" for "context.Context".
+ */
+export function findPackageQualifiers(type: string): string[] {
+    return [...type.matchAll(/(?:^|[^\w.]|\.\.\.)([A-Za-z_]\w*)\./g)].map(match => match[1]);
+}
+
+export function formatImportSpec(name: string, importPath: string): string {
+    return name === defaultPackageName(importPath) ? `"${importPath}"` : `${name} "${importPath}"`;
+}
+
+export function renderMock(spec: MockSpec): string {
+    const qualifier = spec.source ? spec.source.name : undefined;
+    // Exported identifiers of the interface's package need its package name when the mock lives elsewhere
+    const qualify = (type: string) => qualifier ? qualifyType(type, qualifier) : type;
+
+    const methods = spec.methods.map(method => ({
+        name: method.name,
+        params: method.params.map((param, index) => ({
+            name: !param.name || param.name === '_' ? `arg${index}` : RESERVED_NAMES.has(param.name) ? `${param.name}Arg` : param.name,
+            type: qualify(param.type)
+        })),
+        results: method.results.map(qualify)
+    }));
+
+    const interfaceType = qualifier ? `${qualifier}.${spec.interfaceName}` : spec.interfaceName;
+    const lines = [
+        `// Code generated by Go Implementation Lens from ${spec.interfaceName} (${spec.style}); DO NOT EDIT.`,
+        '',
+        `package ${spec.packageName}`,
+        ''
+    ];
+
+    const importPaths = new Set<string>();
+    const types = methods.flatMap(method => [...method.params.map(param => param.type), ...method.results]);
+    for (const type of types) {
+        for (const name of findPackageQualifiers(type)) {
+            const importSpec = name === qualifier ? undefined : spec.imports.get(name);
+            if (importSpec) {
+                importPaths.add(importSpec);
+            }
+        }
+    }
+    if (spec.source) {
+        importPaths.add(formatImportSpec(spec.source.name, spec.source.importPath));
+    }
+    if (spec.style === 'moq') {
+        importPaths.add('"sync"');
+    } else if (spec.style === 'gomock') {
+        importPaths.add('"reflect"');
+        importPaths.add(`"${GOMOCK_IMPORT}"`);
+    }
+    lines.push(...formatImports([...importPaths]));
+
+    switch (spec.style) {
+        case 'funcFields':
+            lines.push(...renderFuncFieldsMock(spec.interfaceName, interfaceType, methods));
+            break;
+        case 'moq':
+            lines.push(...renderMoqMock(spec.interfaceName, interfaceType, methods));
+            break;
+        case 'gomock':
+            lines.push(...renderGomockMock(spec.interfaceName, methods));
+            break;
+    }
+    return lines.join('\n');
+}
+
+type RenderedMethod = { name: string, params: GoParam[], results: string[] };
+
+function formatImports(specs: string[]): string[] {
+    if (specs.length === 0) {
+        return [];
+    }
+
+    // Standard library first, like goimports; import paths of other modules start with a domain
+    const importPath = (spec: string) => spec.substring(spec.indexOf('"'));
+    const isStandard = (spec: string) => !importPath(spec).split('/')[0].includes('.');
+    const sorted = (group: string[]) => group.sort((a, b) => importPath(a).localeCompare(importPath(b))).map(spec => `\t${spec}`);
+    const standard = sorted(specs.filter(isStandard));
+    const others = sorted(specs.filter(spec => !isStandard(spec)));
+
+    return ['import (', ...standard, ...(standard.length > 0 && others.length > 0 ? [''] : []), ...others, ')', ''];
+}
+
+function defaultPackageName(importPath: string): string {
+    const parts = importPath.split('/');
+    const last = parts.pop() || '';
+    // Major version suffixes such as ".../v2" aren't part of the package name
+    return /^v\d+$/.test(last) && parts.length > 0 ? parts.pop()! : last;
+}
+
+function formatSignature(params: GoParam[], results: string[]): string {
+    const resultList = results.length === 0 ? '' : results.length === 1 ? ` ${results[0]}` : ` (${results.join(', ')})`;
+    return `(${params.map(param => `${param.name} ${param.type}`).join(', ')})${resultList}`;
+}
+
+function formatCallArguments(params: GoParam[]): string {
+    return params.map(param => param.type.startsWith('...') ? `${param.name}...` : param.name).join(', ');
+}
+
+// Pads the first column so the rest lines up, the way gofmt aligns struct fields and keyed values
+function alignColumns(rows: [string, string][], indent: string): string[] {
+    const width = Math.max(...rows.map(([first]) => first.length));
+    return rows.map(([first, rest]) => `${indent}${first.padEnd(width)} ${rest}`);
+}
+
+function capitalize(name: string): string {
+    return name.charAt(0).toUpperCase() + name.substring(1);
+}
+
+function renderFuncFieldsMock(interfaceName: string, interfaceType: string, methods: RenderedMethod[]): string[] {
+    const mockName = `${interfaceName}Mock`;
+    const lines = [
+        `// ${mockName} implements ${interfaceName} with a function field per method.`,
+        `// Calling a method whose function field is nil panics.`,
+        `type ${mockName} struct {`,
+        ...alignColumns(methods.map(method => [`${method.name}Func`, `func${formatSignature(method.params, method.results)}`]), '\t'),
+        '}',
+        '',
+        `var _ ${interfaceType} = (*${mockName})(nil)`
+    ];
+
+    for (const method of methods) {
+        const call = `mock.${method.name}Func(${formatCallArguments(method.params)})`;
+        lines.push(
+            '',
+            `func (mock *${mockName}) ${method.name}${formatSignature(method.params, method.results)} {`,
+            `\tif mock.${method.name}Func == nil {`,
+            `\t\tpanic("${mockName}.${method.name}Func: method is nil but ${interfaceName}.${method.name} was just called")`,
+            '\t}',
+            method.results.length > 0 ? `\treturn ${call}` : `\t${call}`,
+            '}'
+        );
+    }
+    return [...lines, ''];
+}
+
+function renderMoqMock(interfaceName: string, interfaceType: string, methods: RenderedMethod[]): string[] {
+    const mockName = `${interfaceName}Mock`;
+    // Recorded arguments; variadic ones are stored as slices
+    const callFields = (method: RenderedMethod): [string, string][] =>
+        method.params.map(param => [capitalize(param.name), param.type.startsWith('...') ? `[]${param.type.substring(3)}` : param.type]);
+    const callStruct = (method: RenderedMethod, indent: string): string[] => method.params.length === 0
+        ? ['struct{}']
+        : ['struct {', ...alignColumns(callFields(method), `${indent}\t`), `${indent}}`];
+
+    const lines = [
+        `var _ ${interfaceType} = &${mockName}{}`,
+        '',
+        `// ${mockName} is a mock implementation of ${interfaceName}.`,
+        `type ${mockName} struct {`
+    ];
+    for (const method of methods) {
+        lines.push(
+            `\t// ${method.name}Func mocks the ${method.name} method.`,
+            `\t${method.name}Func func${formatSignature(method.params, method.results)}`,
+            ''
+        );
+    }
+    lines.push('\t// calls tracks calls to the methods.', '\tcalls struct {');
+    methods.forEach((method, index) => {
+        const [first, ...rest] = callStruct(method, '\t\t');
+        lines.push(
+            `\t\t// ${method.name} holds details about calls to the ${method.name} method.`,
+            `\t\t${method.name} []${first}`,
+            ...rest
+        );
+        if (index < methods.length - 1) {
+            lines.push('');
+        }
+    });
+    lines.push('\t}');
+    lines.push(...alignColumns(methods.map(method => [`lock${method.name}`, 'sync.RWMutex']), '\t'), '}');
+
+    for (const method of methods) {
+        const call = `mock.${method.name}Func(${formatCallArguments(method.params)})`;
+        const [first, ...rest] = callStruct(method, '\t');
+        lines.push(
+            '',
+            `// ${method.name} calls ${method.name}Func.`,
+            `func (mock *${mockName}) ${method.name}${formatSignature(method.params, method.results)} {`,
+            `\tif mock.${method.name}Func == nil {`,
+            `\t\tpanic("${mockName}.${method.name}Func: method is nil but ${interfaceName}.${method.name} was just called")`,
+            '\t}'
+        );
+        if (method.params.length === 0) {
+            lines.push('\tcallInfo := struct{}{}');
+        } else {
+            lines.push(
+                `\tcallInfo := ${first}`,
+                ...rest.slice(0, -1),
+                '\t}{',
+                ...alignColumns(method.params.map(param => [`${capitalize(param.name)}:`, `${param.name},`]), '\t\t'),
+                '\t}'
+            );
+        }
+        lines.push(
+            `\tmock.lock${method.name}.Lock()`,
+            `\tmock.calls.${method.name} = append(mock.calls.${method.name}, callInfo)`,
+            `\tmock.lock${method.name}.Unlock()`,
+            method.results.length > 0 ? `\treturn ${call}` : `\t${call}`,
+            '}'
+        );
+
+        const [callsFirst, ...callsRest] = callStruct(method, '');
+        const [varFirst, ...varRest] = callStruct(method, '\t');
+        lines.push(
+            '',
+            `// ${method.name}Calls gets all the calls that were made to ${method.name}.`,
+            ...(callsRest.length === 0
+                ? [`func (mock *${mockName}) ${method.name}Calls() []${callsFirst} {`]
+                : [`func (mock *${mockName}) ${method.name}Calls() []${callsFirst}`, ...callsRest.slice(0, -1), '} {']),
+            `\tvar calls []${varFirst}`,
+            ...varRest,
+            `\tmock.lock${method.name}.RLock()`,
+            `\tcalls = mock.calls.${method.name}`,
+            `\tmock.lock${method.name}.RUnlock()`,
+            '\treturn calls',
+            '}'
+        );
+    }
+    return [...lines, ''];
+}
+
+function renderGomockMock(interfaceName: string, methods: RenderedMethod[]): string[] {
+    const mockName = `Mock${interfaceName}`;
+    const recorderName = `${mockName}MockRecorder`;
+    const lines = [
+        `// ${mockName} is a mock of ${interfaceName} interface.`,
+        `type ${mockName} struct {`,
+        ...alignColumns([['ctrl', '*gomock.Controller'], ['recorder', `*${recorderName}`]], '\t'),
+        '}',
+        '',
+        `// ${recorderName} is the mock recorder for ${mockName}.`,
+        `type ${recorderName} struct {`,
+        `\tmock *${mockName}`,
+        '}',
+        '',
+        `// New${mockName} creates a new mock instance.`,
+        `func New${mockName}(ctrl *gomock.Controller) *${mockName} {`,
+        `\tmock := &${mockName}{ctrl: ctrl}`,
+        `\tmock.recorder = &${recorderName}{mock}`,
+        '\treturn mock',
+        '}',
+        '',
+        '// EXPECT returns an object that allows the caller to indicate expected use.',
+        `func (m *${mockName}) EXPECT() *${recorderName} {`,
+        '\treturn m.recorder',
+        '}'
+    ];
+
+    for (const method of methods) {
+        const variadic = method.params.length > 0 && method.params[method.params.length - 1].type.startsWith('...')
+            ? method.params[method.params.length - 1]
+            : undefined;
+        const fixed = variadic ? method.params.slice(0, -1) : method.params;
+        const callArguments = variadic ? ', varargs...' : method.params.map(param => `, ${param.name}`).join('');
+
+        lines.push(
+            '',
+            `// ${method.name} mocks base method.`,
+            `func (m *${mockName}) ${method.name}${formatSignature(method.params, method.results)} {`,
+            '\tm.ctrl.T.Helper()'
+        );
+        if (variadic) {
+            lines.push(
+                `\tvarargs := []any{${fixed.map(param => param.name).join(', ')}}`,
+                `\tfor _, a := range ${variadic.name} {`,
+                '\t\tvarargs = append(varargs, a)',
+                '\t}'
+            );
+        }
+        if (method.results.length === 0) {
+            lines.push(`\tm.ctrl.Call(m, "${method.name}"${callArguments})`);
+        } else {
+            lines.push(`\tret := m.ctrl.Call(m, "${method.name}"${callArguments})`);
+            method.results.forEach((result, index) => lines.push(`\tret${index}, _ := ret[${index}].(${result})`));
+            lines.push(`\treturn ${method.results.map((_, index) => `ret${index}`).join(', ')}`);
+        }
+        lines.push('}');
+
+        // The recorder takes matchers or values, so every argument is an any
+        const recorderParams = fixed.length > 0 ? `${fixed.map(param => param.name).join(', ')} any` : '';
+        const recorderVariadic = variadic ? `${recorderParams ? ', ' : ''}${variadic.name} ...any` : '';
+        lines.push(
+            '',
+            `// ${method.name} indicates an expected call of ${method.name}.`,
+            `func (mr *${recorderName}) ${method.name}(${recorderParams}${recorderVariadic}) *gomock.Call {`,
+            '\tmr.mock.ctrl.T.Helper()'
+        );
+        if (variadic) {
+            lines.push(`\tvarargs := append([]any{${fixed.map(param => param.name).join(', ')}}, ${variadic.name}...)`);
+        }
+        lines.push(
+            `\treturn mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "${method.name}", reflect.TypeOf((*${mockName})(nil).${method.name})${callArguments})`,
+            '}'
+        );
+    }
+    return [...lines, ''];
+}
+
+function getMockStyle(config: vscode.WorkspaceConfiguration): MockStyle {
+    const style = config.get<string>('mockStyle', 'funcFields') as MockStyle;
+    return MOCK_STYLES.includes(style) ? style : 'funcFields';
+}
+
+function toSnakeCase(name: string): string {
+    // "HTTPClient" becomes "http_client", "ComplexService3" "complex_service3"
+    return name.replace(/([A-Z]+)([A-Z][a-z])/g, '$1_$2').replace(/([a-z0-9])([A-Z])/g, '$1_$2').toLowerCase();
+}
+
+/**
+ * Import specs of a Go file by the package name they bring into scope.
+ */
+export function parseImports(text: string): Map<string, string> {
+    const specs: string[] = [];
+    for (const block of text.matchAll(/^import\s*\(([\s\S]*?)\)/gm)) {
+        specs.push(...block[1].split('\n').map(line => line.replace(/\/\/.*$/, '').trim()).filter(line => line.length > 0));
+    }
+    for (const single of text.matchAll(/^import\s+((?:[\w.]+\s+)?"[^"]+")/gm)) {
+        specs.push(single[1]);
+    }
+
+    const imports = new Map<string, string>();
+    for (const spec of specs) {
+        const match = spec.match(/^(?:([\w.]+)\s+)?"([^"]+)"/);
+        // Blank and dot imports don't bring a package name into scope
+        if (match && match[1] !== '_' && match[1] !== '.') {
+            imports.set(match[1] || defaultPackageName(match[2]), spec);
+        }
+    }
+    return imports;
+}
+
+/**
+ * Whether the interface embeds other interfaces, whose methods the document symbols don't list.
+ */
+export function hasEmbeddedInterfaces(document: vscode.TextDocument, interfaceInfo: InterfaceInfo): boolean {
+    for (let line = interfaceInfo.range.start.line + 1; line < interfaceInfo.range.end.line; line++) {
+        if (/^\s*(?:\w+\.)?\w+\s*(?:\/\/.*)?$/.test(document.lineAt(line).text)) {
+            return true;
+        }
+    }
+    return false;
+}
+
+export const generateMockLensContributor: LensContributor = {
+    kind: 'generateMock',
+    provideLenses({ document, config, analysis }) {
+        if (!config.get<boolean>('showOnInterfaces', true) || !config.get<boolean>('showGenerateMockLens', true)) {
+            return [];
+        }
+
+        return analysis.interfaces.map(interfaceInfo => ({
+            symbolId: symbolId(interfaceInfo.range),
+            range: interfaceInfo.range,
+            values: {},
+            command: {
+                title: vscode.l10n.t('Generate mock'),
+                command: 'goImplementationLens.generateMock',
+                arguments: [
+                    document.uri.toString(),
+                    { line: interfaceInfo.range.start.line, character: interfaceInfo.range.start.character }
+                ]
+            }
+        }));
+    }
+};
+
+export interface ExistingMock {
+    text: string;
+    // Set for mocks this extension generated
+    header?: { interfaceName: string, style: MockStyle };
+}
+
+/**
+ * Writes mocks of interfaces to the file named by goImplementationLens.mockFilePattern, and
+ * regenerates them when the interface's file is saved with a changed method set.
+ */
+export class MockGenerator implements vscode.Disposable {
+    private disposables: vscode.Disposable[] = [];
+
+    constructor(private goAnalyzer: GoAnalyzer, onDidRefresh: vscode.Event<RefreshEvent>) {
+        this.disposables.push(onDidRefresh(event => this.regenerateMocks(event.saved)));
+    }
+
+    getMockUri(sourceUri: vscode.Uri, interfaceName: string): vscode.Uri {
+        const pattern = vscode.workspace.getConfiguration('goImplementationLens').get<string>('mockFilePattern', DEFAULT_FILE_PATTERN) || DEFAULT_FILE_PATTERN;
+        // Without the interface's name, the mocks of all interfaces in a directory would overwrite each other
+        if (!/\{(?:iface|Interface)\}/.test(pattern)) {
+            throw new Error(vscode.l10n.t('goImplementationLens.mockFilePattern must contain {iface} or {Interface}'));
+        }
+        const relativePath = pattern.replace(/\{iface\}/g, toSnakeCase(interfaceName)).replace(/\{Interface\}/g, interfaceName);
+        // Relative to the interface's directory, so "mocks/..." is a mocks package next to it
+        return vscode.Uri.file(path.resolve(path.dirname(sourceUri.fsPath), relativePath));
+    }
+
+    async readMock(uri: vscode.Uri): Promise<ExistingMock | undefined> {
+        let text: string;
+        try {
+            text = Buffer.from(await vscode.workspace.fs.readFile(uri)).toString('utf8');
+        } catch (error) {
+            return undefined;
+        }
+
+        const match = text.match(HEADER_PATTERN);
+        return {
+            text: text,
+            header: match && MOCK_STYLES.includes(match[2] as MockStyle)
+                ? { interfaceName: match[1], style: match[2] as MockStyle }
+                : undefined
+        };
+    }
+
+    /**
+     * Generates the mock with the configured style and returns where it was written.
+     */
+    async generate(document: vscode.TextDocument, interfaceInfo: InterfaceInfo): Promise<vscode.Uri> {
+        const target = this.getMockUri(document.uri, interfaceInfo.name);
+        const content = await this.render(document, interfaceInfo, getMockStyle(vscode.workspace.getConfiguration('goImplementationLens')), target);
+        await vscode.workspace.fs.createDirectory(vscode.Uri.file(path.dirname(target.fsPath)));
+        await vscode.workspace.fs.writeFile(target, Buffer.from(content, 'utf8'));
+        getLog().info(`Generated ${vscode.workspace.asRelativePath(target)} from ${interfaceInfo.name}`);
+        return target;
+    }
+
+    private async render(document: vscode.TextDocument, interfaceInfo: InterfaceInfo, style: MockStyle, target: vscode.Uri): Promise<string> {
+        const text = document.getText();
+        const sourcePackage = (text.match(/^package\s+(\w+)/m) || [])[1] || 'main';
+        const targetDir = path.dirname(target.fsPath);
+        const samePackage = path.resolve(path.dirname(document.uri.fsPath)) === path.resolve(targetDir);
+        if (!samePackage && sourcePackage === 'main') {
+            throw new Error(vscode.l10n.t('Interfaces in package main cannot be imported, so their mocks must be generated in the same directory'));
+        }
+        if (!samePackage && !/^[A-Z]/.test(interfaceInfo.name)) {
+            throw new Error(vscode.l10n.t('{0} is unexported, so its mock must be generated in the same directory', interfaceInfo.name));
+        }
+        // The document symbols don't list the methods of embedded interfaces, so the mock wouldn't compile
+        if (hasEmbeddedInterfaces(document, interfaceInfo)) {
+            throw new Error(vscode.l10n.t('{0} embeds other interfaces, whose methods cannot be mocked yet', interfaceInfo.name));
+        }
+
+        const methods: GoMethodSignature[] = [];
+        for (const method of interfaceInfo.methods) {
+            // Signatures may continue after the symbol's range on the same line, e.g. with a trailing comment
+            const signature = parseMethodSignature(document.getText(new vscode.Range(method.range.start, document.lineAt(method.range.end.line).range.end)));
+            if (!signature) {
+                throw new Error(vscode.l10n.t('Could not parse the signature of {0}.{1}', interfaceInfo.name, method.name));
+            }
+            methods.push(signature);
+        }
+
+        return renderMock({
+            interfaceName: interfaceInfo.name,
+            style: style,
+            packageName: samePackage ? sourcePackage : await this.getPackageName(targetDir),
+            source: samePackage ? undefined : { name: sourcePackage, importPath: await this.goAnalyzer.getPackagePath(document.uri) },
+            imports: parseImports(text),
+            methods: methods
+        });
+    }
+
+    private async getPackageName(dir: string): Promise<string> {
+        // Join the package of the Go files already in the directory
+        try {
+            for (const [name, type] of await vscode.workspace.fs.readDirectory(vscode.Uri.file(dir))) {
+                if (type === vscode.FileType.File && name.endsWith('.go') && !name.endsWith('_test.go')) {
+                    const content = Buffer.from(await vscode.workspace.fs.readFile(vscode.Uri.file(path.join(dir, name)))).toString('utf8');
+                    const match = content.match(/^package\s+(\w+)/m);
+                    if (match) {
+                        return match[1];
+                    }
+                }
+            }
+        } catch (error) {
+            // The directory doesn't exist yet
+        }
+
+        const name = path.basename(dir).toLowerCase().replace(/[^a-z0-9_]/g, '');
+        return /^[a-z_]/.test(name) ? name : 'mocks';
+    }
+
+    private async regenerateMocks(saved: ReadonlySet<string>) {
+        if (saved.size === 0 || !vscode.workspace.getConfiguration('goImplementationLens').get<boolean>('regenerateMocks', true)) {
+            return;
+        }
+
+        for (const key of saved) {
+            const document = vscode.workspace.textDocuments.find(candidate => candidate.uri.toString() === key);
+            if (!document || document.languageId !== 'go') {
+                continue;
+            }
+
+            const analysis = await this.goAnalyzer.analyzeDocument(document);
+            for (const interfaceInfo of analysis.interfaces) {
+                try {
+                    const target = this.getMockUri(document.uri, interfaceInfo.name);
+                    const existing = await this.readMock(target);
+                    // Only mocks generated from this interface are ours to overwrite
+                    if (!existing || !existing.header || existing.header.interfaceName !== interfaceInfo.name) {
+                        continue;
+                    }
+
+                    const content = await this.render(document, interfaceInfo, existing.header.style, target);
+                    if (content !== existing.text) {
+                        await vscode.workspace.fs.writeFile(target, Buffer.from(content, 'utf8'));
+                        getLog().info(`Regenerated ${vscode.workspace.asRelativePath(target)} after ${interfaceInfo.name} changed`);
+                    }
+                } catch (error) {
+                    getLog().warn(`Regenerating the mock of ${interfaceInfo.name} failed: ${error instanceof Error ? error.message : error}`);
+                }
+            }
+        }
+    }
+
+    dispose() {
+        this.disposables.forEach(disposable => disposable.dispose());
+    }
+}
@@ -0,0 +1,134 @@
+import * as vscode from 'vscode';
+
+export type ReferenceUsage =
+    | 'call'
+    | 'typeAssertion'
+    | 'typeSwitchCase'
+    | 'conversion'
+    | 'compositeLiteral'
+    | 'embedding'
+    | 'fieldDeclaration'
+    | 'signature'
+    | 'assignment'
+    | 'other';
+
+export function getReferenceUsageLabel(usage: ReferenceUsage): string {
+    switch (usage) {
+        case 'call':
+            return vscode.l10n.t('Call');
+        case 'typeAssertion':
+            return vscode.l10n.t('Type assertion');
+        case 'typeSwitchCase':
+            return vscode.l10n.t('Type switch case');
+        case 'conversion':
+            return vscode.l10n.t('Conversion');
+        case 'compositeLiteral':
+            return vscode.l10n.t('Composite literal');
+        case 'embedding':
+            return vscode.l10n.t('Embedding');
+        case 'fieldDeclaration':
+            return vscode.l10n.t('Field declaration');
+        case 'signature':
+            return vscode.l10n.t('Parameter/return type');
+        case 'assignment':
+            return vscode.l10n.t('Assignment');
+        case 'other':
+            return vscode.l10n.t('Other reference');
+    }
+}
+
+export const REFERENCE_USAGE_ICONS: Record<ReferenceUsage, string> = {
+    call: 'call-outgoing',
+    typeAssertion: 'symbol-operator',
+    typeSwitchCase: 'list-tree',
+    conversion: 'arrow-swap',
+    compositeLiteral: 'symbol-structure',
+    embedding: 'layers',
+    fieldDeclaration: 'symbol-field',
+    signature: 'symbol-parameter',
+    assignment: 'edit',
+    other: 'circle-small-filled'
+};
+
+// How far up the classifier looks for the "{" that opens the enclosing block
+const MAX_BLOCK_SCAN_LINES = 200;
+
+/**
+ * Classifies how a reference uses its symbol, based on the Go source around it.
+ * `targetIsType` tells calls apart from conversions, which look the same syntactically.
+ */
+export function classifyReference(document: vscode.TextDocument, range: vscode.Range, targetIsType: boolean): ReferenceUsage {
+    const lineText = stripCommentsAndStrings(document.lineAt(range.start.line).text);
+    const before = lineText.substring(0, range.start.character);
+    const after = range.end.line === range.start.line ? lineText.substring(range.end.character) : '';
+
+    // x.(Name) or x.(*pkg.Name)
+    if (/\.\(\s*\*?(?:\w+\.)?$/.test(before)) {
+        return 'typeAssertion';
+    }
+
+    const blockHeader = findEnclosingBlockHeader(document, range.start.line);
+
+    if (/^\s*case\b/.test(before) && blockHeader !== undefined && /\.\(\s*type\s*\)\s*$/.test(blockHeader)) {
+        return 'typeSwitchCase';
+    }
+
+    if (blockHeader !== undefined && /\b(?:struct|interface)\s*$/.test(blockHeader)) {
+        // A line that is nothing but a (qualified, possibly pointer) type name embeds it
+        if (/^\s*\*?(?:\w+\.)?$/.test(before) && /^\s*(?:`[^`]*`)?\s*$/.test(after)) {
+            return 'embedding';
+        }
+        if (/\binterface\s*$/.test(blockHeader)) {
+            return before.includes('(') ? 'signature' : 'other';
+        }
+        if (/^\s*\w+(?:\s*,\s*\w+)*\s+\S*$/.test(before)) {
+            return 'fieldDeclaration';
+        }
+    }
+
+    // Inside a func signature: the last "func" on the line has not opened its body yet
+    const funcIndex = before.search(/\bfunc\b(?!.*\bfunc\b)/);
+    if (funcIndex !== -1 && !before.substring(funcIndex).includes('{') && before.substring(funcIndex).includes('(')) {
+        return 'signature';
+    }
+
+    if (/^\s*\{/.test(after) && !/^\s*(?:if|for|switch|select)\b/.test(before)) {
+        return 'compositeLiteral';
+    }
+
+    if (/^\s*(?:\[[^\]]*\]\s*)?\(/.test(after)) {
+        return targetIsType ? 'conversion' : 'call';
+    }
+
+    if (/^\s*(?:,\s*[\w.]+\s*)*(?::=|<<=|>>=|&\^=|[-+*/%&|^]=|=(?!=))/.test(after) || /^\s*(?:\+\+|--)/.test(after)) {
+        return 'assignment';
+    }
+
+    return 'other';
+}
+
+function stripCommentsAndStrings(text: string): string {
+    // Keep offsets stable so reference ranges still line up with the stripped text
+    return text
+        .replace(/"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|`[^`]*`/g, match => match[0] + ' '.repeat(match.length - 2) + match[0])
+        .replace(/\/\/.*$/, match => ' '.repeat(match.length));
+}
+
+function findEnclosingBlockHeader(document: vscode.TextDocument, line: number): string | undefined {
+    let depth = 0;
+    const stop = Math.max(0, line - MAX_BLOCK_SCAN_LINES);
+    for (let i = line - 1; i >= stop; i--) {
+        const text = stripCommentsAndStrings(document.lineAt(i).text);
+        for (let j = text.length - 1; j >= 0; j--) {
+            if (text[j] === '}') {
+                depth++;
+            } else if (text[j] === '{') {
+                if (depth === 0) {
+                    return text.substring(0, j);
+                }
+                depth--;
+            }
+        }
+    }
+    return undefined;
+}
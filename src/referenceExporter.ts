@@ -0,0 +1,75 @@
+export type ExportFormat = 'csv' | 'json' | 'markdown';
+
+export interface ExportRow {
+    symbol: string;
+    kind: string;
+    package: string;
+    file: string;
+    line: number;
+    function: string;
+    preview: string;
+}
+
+export const EXPORT_FILE_EXTENSIONS: Record<ExportFormat, string> = {
+    csv: 'csv',
+    json: 'json',
+    markdown: 'md'
+};
+
+const CSV_COLUMNS: (keyof ExportRow)[] = ['symbol', 'kind', 'package', 'file', 'line', 'function', 'preview'];
+
+export function formatReferences(rows: ExportRow[], format: ExportFormat, title: string): string {
+    switch (format) {
+        case 'csv':
+            return formatCsv(rows);
+        case 'json':
+            return JSON.stringify(rows, null, 2) + '\n';
+        case 'markdown':
+            return formatMarkdownChecklist(rows, title);
+    }
+}
+
+function formatCsv(rows: ExportRow[]): string {
+    const lines = [CSV_COLUMNS.join(',')];
+    for (const row of rows) {
+        lines.push(CSV_COLUMNS.map(column => escapeCsvField(String(row[column]))).join(','));
+    }
+    return lines.join('\r\n') + '\r\n';
+}
+
+function escapeCsvField(value: string): string {
+    if (/[",\r\n]/.test(value)) {
+        return `"${value.replace(/"/g, '""')}"`;
+    }
+    return value;
+}
+
+function formatMarkdownChecklist(rows: ExportRow[], title: string): string {
+    const lines = [`## ${title}`, ''];
+
+    // One section per package keeps the checklist reviewable in a ticket
+    const byPackage = new Map<string, ExportRow[]>();
+    for (const row of rows) {
+        if (!byPackage.has(row.package)) {
+            byPackage.set(row.package, []);
+        }
+        byPackage.get(row.package)!.push(row);
+    }
+
+    for (const [packageName, packageRows] of byPackage) {
+        lines.push(`### ${packageName}`, '');
+        for (const row of packageRows) {
+            lines.push(`- [ ] \`${row.file}:${row.line}\` · ${row.kind} · \`${row.function}\`: ${codeSpan(row.preview)}`);
+        }
+        lines.push('');
+    }
+
+    return lines.join('\n');
+}
+
+function codeSpan(text: string): string {
+    // The fence must be longer than any backtick run inside, and padded so backticks at the ends aren't part of it
+    const longestRun = Math.max(0, ...(text.match(/`+/g) || []).map(run => run.length));
+    const fence = '`'.repeat(longestRun + 1);
+    return longestRun > 0 ? `${fence} ${text} ${fence}` : `${fence}${text}${fence}`;
+}
@@ -0,0 +1,142 @@
+import * as vscode from 'vscode';
+import { GoInterfaceCodeLensProvider } from './codeLensProvider';
+import { GoAnalyzer } from './goAnalyzer';
+import { GoInterfaceGutterProvider } from './gutterDecorationProvider';
+import { GoInterfaceInlayHintsProvider } from './inlayHintsProvider';
+import { getLog } from './logger';
+import { GoReferenceSidebarProvider } from './sidebarProvider';
+
+const DEFAULT_DEBOUNCE_MS = 500;
+
+export interface RefreshEvent {
+    // URIs of the documents that were re-analyzed
+    documents: ReadonlySet<string>;
+    // The subset that was refreshed because it was saved
+    saved: ReadonlySet<string>;
+    // Everything was refreshed, after a configuration change or once gopls is ready
+    all: boolean;
+}
+
+/**
+ * Coalesces document edits into one refresh of every view after typing pauses, so a burst of
+ * keystrokes costs a single re-analysis instead of one per keystroke.
+ */
+export class RefreshScheduler implements vscode.Disposable {
+    private _onDidRefresh: vscode.EventEmitter<RefreshEvent> = new vscode.EventEmitter<RefreshEvent>();
+    // Fires after each refresh, for features that derive data from the analysis
+    public readonly onDidRefresh: vscode.Event<RefreshEvent> = this._onDidRefresh.event;
+
+    // URIs edited since the last refresh
+    private pendingDocuments: Set<string> = new Set();
+    private pendingSaved: Set<string> = new Set();
+    // A configuration change re-renders every visible Go editor, not just the edited ones
+    private pendingAll = false;
+    private timer: NodeJS.Timeout | undefined;
+    private disposables: vscode.Disposable[] = [];
+
+    constructor(
+        private goAnalyzer: GoAnalyzer,
+        private codeLensProvider: GoInterfaceCodeLensProvider,
+        private inlayHintsProvider: GoInterfaceInlayHintsProvider,
+        private gutterProvider: GoInterfaceGutterProvider,
+        private sidebarProvider: GoReferenceSidebarProvider
+    ) {
+        this.disposables.push(
+            this._onDidRefresh,
+            vscode.workspace.onDidChangeTextDocument(e => {
+                // Events without content changes only report a changed dirty state
+                if (e.document.languageId === 'go' && e.contentChanges.length > 0) {
+                    this.schedule(e.document);
+                }
+            }),
+            // Saving is a natural pause, so refresh right away
+            vscode.workspace.onDidSaveTextDocument(document => {
+                if (document.languageId === 'go') {
+                    this.pendingDocuments.add(document.uri.toString());
+                    this.pendingSaved.add(document.uri.toString());
+                    this.flush();
+                }
+            }),
+            vscode.workspace.onDidChangeConfiguration(e => {
+                if (e.affectsConfiguration('goImplementationLens')) {
+                    this.pendingAll = true;
+                    this.flush();
+                }
+            })
+        );
+    }
+
+    schedule(document: vscode.TextDocument) {
+        this.pendingDocuments.add(document.uri.toString());
+        this.goAnalyzer.markChanged(document.uri.fsPath);
+        if (this.timer) {
+            clearTimeout(this.timer);
+        }
+        const debounce = vscode.workspace.getConfiguration('goImplementationLens').get<number>('refreshDebounceMs', DEFAULT_DEBOUNCE_MS);
+        this.timer = setTimeout(() => this.flush(), Math.max(0, debounce));
+    }
+
+    /**
+     * Re-analyzes every open Go document and refreshes all views now.
+     */
+    refreshAll() {
+        for (const document of vscode.workspace.textDocuments) {
+            if (document.languageId === 'go') {
+                this.pendingDocuments.add(document.uri.toString());
+            }
+        }
+        this.pendingAll = true;
+        this.flush();
+    }
+
+    /**
+     * Runs the pending refresh now.
+     */
+    flush() {
+        if (this.timer) {
+            clearTimeout(this.timer);
+            this.timer = undefined;
+        }
+
+        const documents = this.pendingDocuments;
+        const saved = this.pendingSaved;
+        const all = this.pendingAll;
+        this.pendingDocuments = new Set();
+        this.pendingSaved = new Set();
+        this.pendingAll = false;
+        if (documents.size === 0 && !all) {
+            return;
+        }
+
+        for (const uri of documents) {
+            this.goAnalyzer.invalidateCache(vscode.Uri.parse(uri).fsPath);
+        }
+
+        // Both providers re-query only the visible range, so one event each covers every document
+        this.codeLensProvider.refresh();
+        this.inlayHintsProvider.refresh();
+
+        for (const editor of vscode.window.visibleTextEditors) {
+            if (editor.document.languageId === 'go' && (all || documents.has(editor.document.uri.toString()))) {
+                this.gutterProvider.updateDecorations(editor);
+            }
+        }
+
+        this.sidebarProvider.documentsChanged(documents);
+        this._onDidRefresh.fire({ documents, saved, all });
+
+        const stats = this.goAnalyzer.getCacheStats();
+        getLog().debug(`Refreshed ${all ? 'all editors' : `${documents.size} edited documents`}; cache hits/misses: ` +
+            `analyses ${stats.analysisHits}/${stats.analysisMisses}, names ${stats.nameHits}/${stats.nameMisses}, ` +
+            `symbols ${stats.symbolHits}/${stats.symbolMisses}; gopls requests ${stats.goplsRequests} (${stats.goplsFailures} failed)`);
+    }
+
+    dispose() {
+        if (this.timer) {
+            clearTimeout(this.timer);
+            this.timer = undefined;
+        }
+        this.disposables.forEach(disposable => disposable.dispose());
+        this.disposables = [];
+    }
+}
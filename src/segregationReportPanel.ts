@@ -0,0 +1,129 @@
+import * as vscode from 'vscode';
+import * as crypto from 'crypto';
+import { SegregationReport, formatSegregationReport } from './interfaceSegregation';
+
+/**
+ * Shows interface segregation reports in a webview beside the editor. The report is rendered from
+ * the same Markdown that "Export as Markdown" writes, so both always agree.
+ */
+export class SegregationReportPanel implements vscode.Disposable {
+    private static current: SegregationReportPanel | undefined;
+
+    private panel: vscode.WebviewPanel;
+    private markdown = '';
+    private interfaceName = '';
+    private disposables: vscode.Disposable[] = [];
+
+    static async show(report: SegregationReport) {
+        if (!SegregationReportPanel.current) {
+            SegregationReportPanel.current = new SegregationReportPanel();
+        }
+        await SegregationReportPanel.current.update(report);
+    }
+
+    private constructor() {
+        this.panel = vscode.window.createWebviewPanel(
+            'goImplementationLens.segregationReport',
+            vscode.l10n.t('Interface Segregation'),
+            { viewColumn: vscode.ViewColumn.Beside, preserveFocus: true },
+            { enableScripts: true, enableFindWidget: true }
+        );
+        this.disposables.push(
+            this.panel.onDidDispose(() => this.dispose()),
+            this.panel.webview.onDidReceiveMessage(message => {
+                if (message && message.command === 'export') {
+                    this.export();
+                }
+            })
+        );
+    }
+
+    private async update(report: SegregationReport) {
+        this.markdown = formatSegregationReport(report);
+        this.interfaceName = report.interfaceName;
+        this.panel.title = vscode.l10n.t('Segregation: {0}', report.interfaceName);
+        this.panel.webview.html = this.getHtml(await renderMarkdown(this.markdown));
+        this.panel.reveal(undefined, true);
+    }
+
+    private async export() {
+        const copyToClipboard = vscode.l10n.t('Copy to Clipboard');
+        const destination = await vscode.window.showQuickPick([copyToClipboard, vscode.l10n.t('Save to File...')], {
+            placeHolder: vscode.l10n.t('Export to')
+        });
+        if (!destination) {
+            return;
+        }
+
+        if (destination === copyToClipboard) {
+            await vscode.env.clipboard.writeText(this.markdown);
+            vscode.window.showInformationMessage(vscode.l10n.t('Copied the report to the clipboard'));
+            return;
+        }
+
+        const uri = await vscode.window.showSaveDialog({
+            filters: { Markdown: ['md'] },
+            defaultUri: vscode.workspace.workspaceFolders
+                ? vscode.Uri.joinPath(vscode.workspace.workspaceFolders[0].uri, `${this.interfaceName}-segregation.md`)
+                : undefined
+        });
+        if (uri) {
+            await vscode.workspace.fs.writeFile(uri, Buffer.from(this.markdown, 'utf8'));
+            vscode.window.showInformationMessage(vscode.l10n.t('Exported the report to {0}', vscode.workspace.asRelativePath(uri)));
+        }
+    }
+
+    private getHtml(body: string): string {
+        const nonce = crypto.randomBytes(16).toString('base64');
+        return `<!DOCTYPE html>
+<html lang="${vscode.env.language}">
+<head>
+    <meta charset="UTF-8">
+    <meta http-equiv="Content-Security-Policy" content="default-src 'none'; style-src 'unsafe-inline'; script-src 'nonce-${nonce}';">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <style>
+        body { font-family: var(--vscode-font-family); font-size: var(--vscode-font-size); color: var(--vscode-foreground); padding: 0 20px 20px; }
+        table { border-collapse: collapse; margin: 8px 0; }
+        th, td { border: 1px solid var(--vscode-panel-border); padding: 4px 8px; }
+        code, pre { font-family: var(--vscode-editor-font-family); }
+        pre { background: var(--vscode-textCodeBlock-background); padding: 8px; overflow-x: auto; }
+        .toolbar { position: sticky; top: 0; padding: 8px 0; background: var(--vscode-editor-background); }
+        button { color: var(--vscode-button-foreground); background: var(--vscode-button-background); border: none; padding: 4px 12px; cursor: pointer; }
+        button:hover { background: var(--vscode-button-hoverBackground); }
+    </style>
+</head>
+<body>
+    <div class="toolbar"><button id="export">${escapeHtml(vscode.l10n.t('Export as Markdown'))}</button></div>
+    ${body}
+    <script nonce="${nonce}">
+        const vscode = acquireVsCodeApi();
+        document.getElementById('export').addEventListener('click', () => vscode.postMessage({ command: 'export' }));
+    </script>
+</body>
+</html>`;
+    }
+
+    dispose() {
+        SegregationReportPanel.current = undefined;
+        this.panel.dispose();
+        this.disposables.forEach(disposable => disposable.dispose());
+        this.disposables = [];
+    }
+}
+
+async function renderMarkdown(markdown: string): Promise<string> {
+    try {
+        // Rendered by VS Code's built-in Markdown extension, which sanitizes the output
+        const html = await vscode.commands.executeCommand<string>('markdown.api.render', markdown);
+        if (html) {
+            return html;
+        }
+    } catch (error) {
+        // The Markdown extension is disabled
+    }
+    return `<pre>${escapeHtml(markdown)}</pre>`;
+}
+
+function escapeHtml(text: string): string {
+    return text.replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/>/g, '&gt;').replace(/"/g, '&quot;');
+}
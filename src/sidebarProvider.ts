@@ -1,5 +1,9 @@
 import * as vscode from 'vscode';
 import { GoAnalyzer } from './goAnalyzer';
+import { classifyReference, getReferenceUsageLabel, ReferenceUsage, REFERENCE_USAGE_ICONS } from './referenceClassifier';
+import { isSingular } from './l10n';
+import { ExportRow } from './referenceExporter';
+import { isMockDocument } from './implementationCategory';
 import * as path from 'path';
 
 export interface ReferenceItem {
@@ -9,8 +13,37 @@ export interface ReferenceItem {
     linePreview: string;
     fileName: string;
     packageName?: string;
+    enclosingSymbol?: string;
+    usage?: ReferenceUsage;
+    // Implementations in mock files, so they can be filtered apart from real ones
+    mock?: boolean;
 }
 
+// Implementations are filtered alongside the usage kinds of references
+export type ReferenceFilterKind = ReferenceUsage | 'implementation' | 'mock';
+
+export function getReferenceKindLabel(kind: ReferenceFilterKind): string {
+    switch (kind) {
+        case 'implementation':
+            return vscode.l10n.t('Implementation');
+        case 'mock':
+            return vscode.l10n.t('Mock');
+        default:
+            return getReferenceUsageLabel(kind);
+    }
+}
+
+function formatReferenceCount(count: number): string {
+    return isSingular(count) ? vscode.l10n.t('{0} reference', count) : vscode.l10n.t('{0} references', count);
+}
+
+const TYPE_SYMBOL_KINDS = [
+    vscode.SymbolKind.Interface,
+    vscode.SymbolKind.Struct,
+    vscode.SymbolKind.Class,
+    vscode.SymbolKind.TypeParameter
+];
+
 export interface FunctionGroup {
     functionName: string;
     items: ReferenceItem[];
@@ -29,6 +62,11 @@ export class GoReferenceSidebarProvider implements vscode.TreeDataProvider<Refer
 
     private currentItems: ReferenceItem[] = [];
     private currentSymbol: string = '';
+    private currentSymbolKind: vscode.SymbolKind | undefined;
+    private currentTitle: string | undefined;
+    private kindFilter: Set<ReferenceFilterKind> | undefined;
+    private searchQuery: string | undefined;
+    private searchPattern: RegExp | undefined;
 
     constructor(private goAnalyzer: GoAnalyzer) {}
 
@@ -36,9 +74,31 @@ export class GoReferenceSidebarProvider implements vscode.TreeDataProvider<Refer
         this._onDidChangeTreeData.fire();
     }
 
-    updateReferences(symbolName: string, implementations: vscode.Location[], references: vscode.Location[]) {
+    /**
+     * Re-resolves previews and enclosing functions of items in edited documents.
+     */
+    documentsChanged(uris: ReadonlySet<string>) {
+        let changed = false;
+        for (const item of this.currentItems) {
+            if (uris.has(item.location.uri.toString())) {
+                item.enclosingSymbol = undefined;
+                changed = true;
+            }
+        }
+        if (changed) {
+            this.refresh();
+        }
+    }
+
+    updateReferences(symbolName: string, implementations: vscode.Location[], references: vscode.Location[], symbolKind?: vscode.SymbolKind, title?: string) {
         this.currentSymbol = symbolName;
+        this.currentSymbolKind = symbolKind;
+        this.currentTitle = title;
         this.currentItems = [];
+        this.kindFilter = undefined;
+        this.searchQuery = undefined;
+        this.searchPattern = undefined;
+        this.updateFilterContext();
 
         // Add implementations
         implementations.forEach(impl => {
@@ -65,33 +125,196 @@ export class GoReferenceSidebarProvider implements vscode.TreeDataProvider<Refer
         this.refresh();
     }
 
+    async getKindCounts(): Promise<Map<ReferenceFilterKind, number>> {
+        await this.resolveItemDetails();
+        
+        const counts = new Map<ReferenceFilterKind, number>();
+        for (const item of this.currentItems) {
+            const kind = this.getFilterKind(item);
+            counts.set(kind, (counts.get(kind) || 0) + 1);
+        }
+        return counts;
+    }
+
+    getKindFilter(): ReadonlySet<ReferenceFilterKind> | undefined {
+        return this.kindFilter;
+    }
+
+    setKindFilter(kinds: Set<ReferenceFilterKind> | undefined) {
+        this.kindFilter = kinds && kinds.size > 0 ? kinds : undefined;
+        this.updateFilterContext();
+        this.refresh();
+    }
+
+    getSearchQuery(): string | undefined {
+        return this.searchQuery;
+    }
+
+    /**
+     * Narrows the tree to items whose file path, enclosing function or line matches the query.
+     * Queries written as /pattern/flags are regular expressions, anything else is a
+     * case-insensitive substring. Returns an error message if the regular expression is invalid.
+     */
+    setSearchQuery(query: string | undefined): string | undefined {
+        let pattern: RegExp | undefined;
+        if (query) {
+            const regexMatch = query.match(/^\/(.+)\/([imsu]*)$/);
+            try {
+                pattern = regexMatch
+                    ? new RegExp(regexMatch[1], regexMatch[2] + 'g')
+                    : new RegExp(query.replace(/[.*+?^${}()|[\]\\]/g, '\\$&'), 'gi');
+            } catch (error) {
+                return vscode.l10n.t('Invalid regular expression: {0}', error instanceof Error ? error.message : String(error));
+            }
+        }
+        
+        this.searchQuery = pattern ? query : undefined;
+        this.searchPattern = pattern;
+        this.updateFilterContext();
+        this.refresh();
+        return undefined;
+    }
+
+    clearFilters() {
+        this.kindFilter = undefined;
+        this.searchQuery = undefined;
+        this.searchPattern = undefined;
+        this.updateFilterContext();
+        this.refresh();
+    }
+
+    private updateFilterContext() {
+        const active = this.kindFilter !== undefined || this.searchPattern !== undefined;
+        vscode.commands.executeCommand('setContext', 'goImplementationLens.referenceFilterActive', active);
+    }
+
+    private findMatches(text: string): [number, number][] {
+        const pattern = this.searchPattern;
+        if (!pattern) {
+            return [];
+        }
+        
+        const matches: [number, number][] = [];
+        pattern.lastIndex = 0;
+        let match: RegExpExecArray | null;
+        while ((match = pattern.exec(text)) !== null) {
+            if (match[0].length === 0) {
+                // Zero-length matches cannot be highlighted; step past them to avoid looping forever
+                pattern.lastIndex++;
+                continue;
+            }
+            matches.push([match.index, match.index + match[0].length]);
+        }
+        return matches;
+    }
+
+    private matchesSearch(item: ReferenceItem): boolean {
+        return this.findMatches(vscode.workspace.asRelativePath(item.location.uri)).length > 0 ||
+            this.findMatches(item.enclosingSymbol || '').length > 0 ||
+            this.findMatches(item.linePreview).length > 0;
+    }
+
+    private getFilterKind(item: ReferenceItem): ReferenceFilterKind {
+        if (item.type === 'implementation') {
+            return item.mock ? 'mock' : 'implementation';
+        }
+        return item.usage || 'other';
+    }
+
+    private getVisibleItems(): ReferenceItem[] {
+        const filter = this.kindFilter;
+        return this.currentItems.filter(item =>
+            (!filter || filter.has(this.getFilterKind(item))) &&
+            (!this.searchPattern || this.matchesSearch(item)));
+    }
+
+    getTitle(): string {
+        if (this.currentTitle) {
+            return this.currentTitle;
+        }
+        
+        // Determine what type of results we're showing
+        const hasImplementations = this.currentItems.some(item => item.type === 'implementation');
+        const hasReferences = this.currentItems.some(item => item.type === 'reference');
+        
+        if (hasImplementations && hasReferences) {
+            return vscode.l10n.t('References and Implementations to {0}', this.currentSymbol);
+        } else if (hasImplementations) {
+            return vscode.l10n.t('Implementations to {0}', this.currentSymbol);
+        } else if (hasReferences) {
+            return vscode.l10n.t('References to {0}', this.currentSymbol);
+        }
+        return this.currentSymbol;
+    }
+
+    async getExportRows(): Promise<ExportRow[]> {
+        await this.resolveItemDetails();
+        
+        // Export what the tree currently shows, so filters carry over into the export
+        return this.getVisibleItems().map(item => ({
+            symbol: item.symbolName,
+            kind: getReferenceKindLabel(this.getFilterKind(item)),
+            package: item.packageName || '',
+            file: vscode.workspace.asRelativePath(item.location.uri),
+            line: item.location.range.start.line + 1,
+            function: item.enclosingSymbol || vscode.l10n.t('global'),
+            preview: item.linePreview
+        }));
+    }
+
     getTreeItem(element: ReferenceTreeItem): vscode.TreeItem {
         return element;
     }
 
+    async resolveTreeItem(item: vscode.TreeItem, element: ReferenceTreeItem): Promise<vscode.TreeItem> {
+        // Context previews are only built when the user hovers, since they need the document text
+        if (element.contextValue === 'reference' && element.location) {
+            try {
+                const contextLines = vscode.workspace.getConfiguration('goImplementationLens').get<number>('previewContextLines', 3);
+                item.tooltip = await this.buildContextPreview(element.location, contextLines, element.previewHeading);
+            } catch (error) {
+                // Fall back to a plain tooltip if the document cannot be read
+                item.tooltip = element.previewHeading;
+            }
+        }
+        return item;
+    }
+
+    private async buildContextPreview(location: vscode.Location, contextLines: number, heading?: string): Promise<vscode.MarkdownString> {
+        const doc = await vscode.workspace.openTextDocument(location.uri);
+        const targetLine = location.range.start.line;
+        const firstLine = Math.max(0, targetLine - contextLines);
+        const lastLine = Math.min(doc.lineCount - 1, targetLine + contextLines);
+        
+        const lines: string[] = [];
+        for (let i = firstLine; i <= lastLine; i++) {
+            lines.push(doc.lineAt(i).text.replace(/\t/g, '    '));
+        }
+        
+        // Strip the indentation shared by all non-blank lines so deeply nested code stays readable
+        const indents = lines.filter(line => line.trim()).map(line => line.length - line.trimStart().length);
+        const commonIndent = indents.length > 0 ? Math.min(...indents) : 0;
+        const code = lines.map(line => line.substring(Math.min(commonIndent, line.length))).join('\n');
+        
+        const markdown = new vscode.MarkdownString();
+        markdown.appendMarkdown(`**${path.basename(location.uri.fsPath)}:${targetLine + 1}**`);
+        if (heading) {
+            markdown.appendText(` · ${heading}`);
+        }
+        markdown.appendMarkdown('\n\n');
+        markdown.appendCodeblock(code, 'go');
+        return markdown;
+    }
+
     async getChildren(element?: ReferenceTreeItem): Promise<ReferenceTreeItem[]> {
         if (!element) {
             // Root level - show symbol name as title
             const rootItems: ReferenceTreeItem[] = [];
             
-            // Determine what type of results we're showing
-            const hasImplementations = this.currentItems.some(item => item.type === 'implementation');
-            const hasReferences = this.currentItems.some(item => item.type === 'reference');
-            
-            let titlePrefix = '';
-            if (hasImplementations && hasReferences) {
-                titlePrefix = 'References and Implementations';
-            } else if (hasImplementations) {
-                titlePrefix = 'Implementations';
-            } else if (hasReferences) {
-                titlePrefix = 'References';
-            }
-            
             // Add symbol header if we have a symbol name
-            if (this.currentSymbol && this.currentSymbol !== 'Symbol') {
-                const title = titlePrefix ? `${titlePrefix} to` : '';
-                rootItems.push(new ReferenceTreeItem(
-                    title ? `${title} ${this.currentSymbol}` : this.currentSymbol,
+            if (this.currentTitle || (this.currentSymbol && this.currentSymbol !== 'Symbol')) {
+                const header = new ReferenceTreeItem(
+                    this.getTitle(),
                     vscode.TreeItemCollapsibleState.None,
                     'title',
                     undefined,
@@ -99,15 +322,25 @@ export class GoReferenceSidebarProvider implements vscode.TreeDataProvider<Refer
                     undefined,
                     undefined,
                     'symbol'
-                ));
+                );
+                if (this.searchQuery) {
+                    header.description = vscode.l10n.t('matching {0}', this.searchQuery);
+                }
+                if (this.currentItems.length > 0 && this.currentItems.every(item => item.type === 'implementation')) {
+                    header.iconPath = new vscode.ThemeIcon('symbol-interface');
+                }
+                rootItems.push(header);
             }
             
+            await this.resolveItemDetails();
+            const visibleItems = this.getVisibleItems();
+            
             // Group all items by package/module
             const packageGroups = new Map<string, FileGroup[]>();
             
-            for (const item of this.currentItems) {
+            for (const item of visibleItems) {
                 const filePath = item.location.uri.fsPath;
-                const packageName = this.extractPackageName(filePath);
+                const packageName = item.packageName!;
                 
                 if (!packageGroups.has(packageName)) {
                     packageGroups.set(packageName, []);
@@ -128,10 +361,7 @@ export class GoReferenceSidebarProvider implements vscode.TreeDataProvider<Refer
             
             // Create package nodes
             for (const [packageName, fileGroups] of packageGroups) {
-                const totalRefs = this.currentItems.filter(item => {
-                    const pkg = this.extractPackageName(item.location.uri.fsPath);
-                    return pkg === packageName;
-                }).length;
+                const totalRefs = visibleItems.filter(item => item.packageName === packageName).length;
                 
                 rootItems.push(new ReferenceTreeItem(
                     packageName,
@@ -139,7 +369,7 @@ export class GoReferenceSidebarProvider implements vscode.TreeDataProvider<Refer
                     'package',
                     undefined,
                     undefined,
-                    `${totalRefs} reference${totalRefs !== 1 ? 's' : ''}`,
+                    formatReferenceCount(totalRefs),
                     undefined,
                     undefined,
                     packageName
@@ -148,7 +378,7 @@ export class GoReferenceSidebarProvider implements vscode.TreeDataProvider<Refer
             
             if (rootItems.length === 0 || (rootItems.length === 1 && rootItems[0].contextValue === 'title')) {
                 rootItems.push(new ReferenceTreeItem(
-                    'No references found',
+                    this.kindFilter || this.searchPattern ? vscode.l10n.t('No references match the current filter') : vscode.l10n.t('No references found'),
                     vscode.TreeItemCollapsibleState.None,
                     'empty'
                 ));
@@ -163,11 +393,10 @@ export class GoReferenceSidebarProvider implements vscode.TreeDataProvider<Refer
             // Group items by file within this package
             const fileMap = new Map<string, ReferenceItem[]>();
             
-            for (const item of this.currentItems) {
+            for (const item of this.getVisibleItems()) {
                 const filePath = item.location.uri.fsPath;
-                const itemPackage = this.extractPackageName(filePath);
                 
-                if (itemPackage === packageName) {
+                if (item.packageName === packageName) {
                     if (!fileMap.has(filePath)) {
                         fileMap.set(filePath, []);
                     }
@@ -179,31 +408,32 @@ export class GoReferenceSidebarProvider implements vscode.TreeDataProvider<Refer
             const fileNodes: ReferenceTreeItem[] = [];
             for (const [filePath, items] of fileMap) {
                 const fileName = path.basename(filePath);
-                fileNodes.push(new ReferenceTreeItem(
+                const node = new ReferenceTreeItem(
                     fileName,
                     vscode.TreeItemCollapsibleState.Expanded,
                     'file',
                     undefined,
                     undefined,
-                    `${items.length} reference${items.length !== 1 ? 's' : ''}`,
+                    formatReferenceCount(items.length),
                     filePath,
                     undefined,
                     undefined
-                ));
+                );
+                node.highlight(this.findMatches(fileName));
+                fileNodes.push(node);
             }
             
             return fileNodes;
         } else if (element.contextValue === 'file') {
             // Show functions under file
             const filePath = element.filePath!;
-            const items = this.currentItems.filter(item => item.location.uri.fsPath === filePath);
+            const items = this.getVisibleItems().filter(item => item.location.uri.fsPath === filePath);
             
             // Group items by function
             const functionGroups = new Map<string, ReferenceItem[]>();
             
             for (const item of items) {
-                const doc = await vscode.workspace.openTextDocument(item.location.uri);
-                const functionName = await this.extractFunctionName(doc, item.location.range.start.line);
+                const functionName = item.enclosingSymbol || vscode.l10n.t('global');
                 
                 if (!functionGroups.has(functionName)) {
                     functionGroups.set(functionName, []);
@@ -226,6 +456,7 @@ export class GoReferenceSidebarProvider implements vscode.TreeDataProvider<Refer
                     undefined
                 );
                 node.funcItems = functionItems;
+                node.highlight(this.findMatches(functionName));
                 functionNodes.push(node);
             }
             
@@ -243,71 +474,83 @@ export class GoReferenceSidebarProvider implements vscode.TreeDataProvider<Refer
                 // Show the actual line of code
                 const codeSnippet = linePreview.length > 60 ? linePreview.substring(0, 60) + '...' : linePreview;
                 
-                return new ReferenceTreeItem(
+                const usageLabel = item.usage ? getReferenceUsageLabel(item.usage) : item.mock ? vscode.l10n.t('Mock') : undefined;
+                const node = new ReferenceTreeItem(
                     codeSnippet,
                     vscode.TreeItemCollapsibleState.None,
                     'reference',
                     item.type,
                     item.location,
-                    `Line ${lineNumber}`,
+                    linePreview,
                     undefined,
-                    `Line ${lineNumber}`
+                    usageLabel ? vscode.l10n.t('Line {0} · {1}', lineNumber, usageLabel) : vscode.l10n.t('Line {0}', lineNumber)
                 );
+                node.previewHeading = usageLabel ? vscode.l10n.t('{0} · Line {1}', usageLabel, lineNumber) : vscode.l10n.t('Line {0}', lineNumber);
+                if (item.type === 'implementation') {
+                    node.iconPath = new vscode.ThemeIcon(item.mock ? 'beaker' : 'symbol-class');
+                } else if (item.usage) {
+                    node.iconPath = new vscode.ThemeIcon(REFERENCE_USAGE_ICONS[item.usage]);
+                }
+                node.highlight(this.findMatches(codeSnippet));
+                return node;
             }));
         }
         
         return [];
     }
     
-    private extractPackageName(filePath: string): string {
-        // Extract package name from file path
-        const parts = filePath.split(path.sep);
-        const srcIndex = parts.lastIndexOf('src');
-        const internalIndex = parts.lastIndexOf('internal');
-        const pkgIndex = parts.lastIndexOf('pkg');
-        
-        let startIndex = Math.max(srcIndex, internalIndex, pkgIndex);
-        if (startIndex === -1) {
-            // If no standard Go directory structure, use the parent directory
-            return parts[parts.length - 2] || 'unknown';
-        }
-        
-        // Build package path from the standard directory
-        const packageParts = parts.slice(startIndex + 1, -1);
-        return packageParts.join('/') || parts[startIndex + 1] || 'unknown';
-    }
-    
-    private async extractFunctionName(document: vscode.TextDocument, lineNumber: number): Promise<string> {
-        // Search backwards to find the containing function
-        for (let i = lineNumber; i >= 0; i--) {
-            const line = document.lineAt(i).text;
-            const funcMatch = line.match(/^func\s+(?:\([^)]*\)\s*)?(\w+)/);
-            if (funcMatch) {
-                return `func ${funcMatch[1]}`;
+    private async resolveItemDetails(): Promise<void> {
+        // Resolve package paths, enclosing symbols, line previews and usage kinds once per result set, one document at a time
+        const itemsByFile = new Map<string, ReferenceItem[]>();
+        for (const item of this.currentItems) {
+            if (item.packageName !== undefined && item.enclosingSymbol !== undefined) {
+                continue;
             }
+            const key = item.location.uri.toString();
+            if (!itemsByFile.has(key)) {
+                itemsByFile.set(key, []);
+            }
+            itemsByFile.get(key)!.push(item);
         }
         
-        // If not in a function, check if it's a type definition
-        const currentLine = document.lineAt(lineNumber).text;
-        const typeMatch = currentLine.match(/^type\s+(\w+)/);
-        if (typeMatch) {
-            return `type ${typeMatch[1]}`;
-        }
-        
-        const varMatch = currentLine.match(/^(?:var|const)\s+(\w+)/);
-        if (varMatch) {
-            return varMatch[1];
+        for (const items of itemsByFile.values()) {
+            const uri = items[0].location.uri;
+            const packageName = await this.goAnalyzer.getPackagePath(uri);
+            
+            let doc: vscode.TextDocument | undefined;
+            let enclosingSymbols: string[];
+            try {
+                doc = await vscode.workspace.openTextDocument(uri);
+                enclosingSymbols = await this.goAnalyzer.getEnclosingSymbolNames(doc, items.map(item => item.location.range.start));
+            } catch (error) {
+                enclosingSymbols = items.map(() => vscode.l10n.t('global'));
+            }
+            
+            const targetIsType = this.currentSymbolKind !== undefined && TYPE_SYMBOL_KINDS.includes(this.currentSymbolKind);
+            items.forEach((item, index) => {
+                item.packageName = packageName;
+                item.enclosingSymbol = enclosingSymbols[index];
+                if (doc) {
+                    item.linePreview = doc.lineAt(item.location.range.start.line).text.trim();
+                }
+                if (doc && item.type === 'reference') {
+                    item.usage = classifyReference(doc, item.location.range, targetIsType);
+                }
+                if (doc && item.type === 'implementation') {
+                    item.mock = isMockDocument(doc);
+                }
+            });
         }
-        
-        return 'global';
     }
 }
 
 export class ReferenceTreeItem extends vscode.TreeItem {
     public funcItems?: ReferenceItem[];
+    // "Call · Line 12" above the context preview of references
+    public previewHeading?: string;
     
     constructor(
-        public readonly label: string,
+        label: string,
         public readonly collapsibleState: vscode.TreeItemCollapsibleState,
         public readonly contextValue: string,
         public readonly referenceType?: 'implementation' | 'reference',
@@ -322,15 +565,8 @@ export class ReferenceTreeItem extends vscode.TreeItem {
         if (contextValue === 'title') {
             // Use different icons based on what's being shown
             if (customDescription === 'symbol') {
-                const hasRefs = label.includes('References');
-                const hasImpl = label.includes('Implementations');
-                if (hasRefs && hasImpl) {
-                    this.iconPath = new vscode.ThemeIcon('references');
-                } else if (hasImpl) {
-                    this.iconPath = new vscode.ThemeIcon('symbol-interface');
-                } else {
-                    this.iconPath = new vscode.ThemeIcon('references');
-                }
+                // Headers showing only implementations switch to the interface icon in getChildren
+                this.iconPath = new vscode.ThemeIcon('references');
             } else {
                 this.iconPath = new vscode.ThemeIcon('symbol-key');
             }
@@ -344,11 +580,11 @@ export class ReferenceTreeItem extends vscode.TreeItem {
         } else if (contextValue === 'function') {
             this.iconPath = new vscode.ThemeIcon('symbol-method');
         } else if (contextValue === 'reference' && location) {
-            this.tooltip = linePreview || '';
+            // Left undefined, so hovering asks resolveTreeItem for the context preview
             this.description = customDescription || '';
             this.command = {
                 command: 'goImplementationLens.openReference',
-                title: 'Open',
+                title: vscode.l10n.t('Open'),
                 arguments: [location]
             };
             this.iconPath = new vscode.ThemeIcon('circle-small-filled');
@@ -356,4 +592,10 @@ export class ReferenceTreeItem extends vscode.TreeItem {
             this.iconPath = new vscode.ThemeIcon('info');
         }
     }
-}
\ No newline at end of file
+
+    highlight(ranges: [number, number][]) {
+        if (ranges.length > 0 && typeof this.label === 'string') {
+            this.label = { label: this.label, highlights: ranges };
+        }
+    }
+}
@@ -0,0 +1,87 @@
+import * as vscode from 'vscode';
+import { GoAnalyzer } from './goAnalyzer';
+import { isSingular } from './l10n';
+
+export class ImplementationStatusBar implements vscode.Disposable {
+    private item: vscode.StatusBarItem;
+    private indexing = false;
+    private disposables: vscode.Disposable[] = [];
+
+    constructor(private goAnalyzer: GoAnalyzer) {
+        this.item = vscode.window.createStatusBarItem('goImplementationLens.status', vscode.StatusBarAlignment.Right, 100);
+        this.item.name = vscode.l10n.t('Go Implementation Lens');
+        this.item.command = 'goImplementationLens.showStatusMenu';
+
+        this.disposables.push(
+            goAnalyzer.onDidChangeActivity(() => this.update()),
+            vscode.window.onDidChangeActiveTextEditor(() => this.update())
+        );
+        this.update();
+    }
+
+    /**
+     * Shown while waiting for gopls to load the workspace after startup.
+     */
+    setIndexing(indexing: boolean) {
+        this.indexing = indexing;
+        this.update();
+    }
+
+    update() {
+        const health = this.goAnalyzer.getGoplsHealth();
+        const document = vscode.window.activeTextEditor?.document;
+        const isGo = document !== undefined && document.languageId === 'go';
+
+        if (this.indexing) {
+            this.item.text = `$(sync~spin) ${vscode.l10n.t('Indexing implementations…')}`;
+            this.item.tooltip = vscode.l10n.t('Waiting for the Go language server (gopls) to load the workspace');
+            this.item.backgroundColor = undefined;
+            this.item.show();
+            return;
+        }
+
+        if (!isGo) {
+            this.item.hide();
+            return;
+        }
+
+        const analysis = this.goAnalyzer.getCachedAnalysis(document);
+        const interfaceCount = analysis ? analysis.interfaces.length : 0;
+        const implementerCount = analysis
+            ? analysis.types.filter(typeInfo => typeInfo.implementedInterfaces.length > 0).length +
+              analysis.methodImplementations.filter(methodImpl => methodImpl.interfaceMethod).length
+            : 0;
+
+        const icon = health.failing ? '$(warning)' : this.goAnalyzer.isAnalyzing() ? '$(sync~spin)' : '$(symbol-interface)';
+        this.item.text = analysis
+            ? `${icon} ${interfaceCount} $(arrow-up) ${implementerCount}`
+            : icon;
+
+        const tooltip = new vscode.MarkdownString();
+        if (analysis) {
+            tooltip.appendText(vscode.l10n.t('{0}, {1} in this file',
+                isSingular(interfaceCount)
+                    ? vscode.l10n.t('{0} interface', interfaceCount)
+                    : vscode.l10n.t('{0} interfaces', interfaceCount),
+                isSingular(implementerCount)
+                    ? vscode.l10n.t('{0} implementing type or method', implementerCount)
+                    : vscode.l10n.t('{0} implementing types and methods', implementerCount)));
+        }
+        if (this.goAnalyzer.isAnalyzing()) {
+            tooltip.appendMarkdown('\n\n');
+            tooltip.appendText(vscode.l10n.t('Analyzing…'));
+        }
+        if (health.failing) {
+            tooltip.appendMarkdown('\n\n');
+            tooltip.appendText(vscode.l10n.t('gopls requests are failing: {0}', health.lastError || ''));
+        }
+        this.item.tooltip = tooltip;
+        this.item.backgroundColor = health.failing ? new vscode.ThemeColor('statusBarItem.warningBackground') : undefined;
+        this.item.show();
+    }
+
+    dispose() {
+        this.disposables.forEach(disposable => disposable.dispose());
+        this.item.dispose();
+    }
+}
@@ -13,7 +13,8 @@ async function main() {
         await runTests({ 
             extensionDevelopmentPath, 
             extensionTestsPath,
-            launchArgs: ['--disable-extensions'] // Disable other extensions during testing
+            // The repository is opened as the workspace, so the fixtures count as workspace code
+            launchArgs: [extensionDevelopmentPath, '--disable-extensions'] // Disable other extensions during testing
         });
     } catch (err) {
         console.error('Failed to run tests', err);
@@ -1,7 +1,17 @@
 import * as assert from 'assert';
 import * as vscode from 'vscode';
 import * as path from 'path';
-import { GoAnalyzer } from '../../goAnalyzer';
+import { GoAnalyzer, MethodInfo } from '../../goAnalyzer';
+import { formatReferences } from '../../referenceExporter';
+import { GoReferenceSidebarProvider, ReferenceTreeItem } from '../../sidebarProvider';
+import { classifyReference } from '../../referenceClassifier';
+import { formatLensTitle } from '../../lensRegistry';
+import { clusterMethods, getMethodSignature } from '../../interfaceSegregation';
+import { findDeadImplementations, findUnusedInterfaceMethods } from '../../interfaceHealth';
+import { parseMethodSignature } from '../../mockGenerator';
+import { buildInterfaceMethodRename, validateMethodName } from '../../interfaceMethodRename';
+import { findInterfaceMethodAt, findMethodTargets } from '../../interfaceMethodTargets';
+import { buildSignatureChange, getDefaultArgument, parseNewParameter } from '../../interfaceMethodSignature';
 
 suite('Go Interface Lens Test Suite', () => {
     let analyzer: GoAnalyzer;
@@ -133,8 +143,502 @@ suite('Go Interface Lens Test Suite', () => {
         console.log(`✅ Performance test PASSED: Analysis completed in ${analysisTime}ms`);
         console.log(`   Found ${result.interfaces.length} interfaces, ${result.types.length} types, ${result.methodImplementations.length} method implementations`);
     });
+
+    test('Sidebar - Package path is resolved from go.mod', async () => {
+        const testFile = path.join(__dirname, '../../../test/test_cases.go');
+        
+        const packagePath = await analyzer.getPackagePath(vscode.Uri.file(testFile));
+        
+        assert.strictEqual(packagePath, 'go-implementation-lens/test',
+            'Package path should be the module path joined with the directory');
+    });
+
+    test('Sidebar - Code inside a func literal is attributed to a closure', async () => {
+        const testFile = path.join(__dirname, '../../../test/edge_cases.go');
+        const document = await vscode.workspace.openTextDocument(testFile);
+        
+        // The returned func literal in ComplexImpl.GetFunction
+        const line = document.lineAt(74);
+        const position = new vscode.Position(74, line.text.lastIndexOf('return'));
+        const [name] = await analyzer.getEnclosingSymbolNames(document, [position]);
+        
+        assert.ok(name.includes('GetFunction'), `Enclosing function should be GetFunction (got ${name})`);
+        assert.ok(name.endsWith('→ closure'), `Position should be inside a closure (got ${name})`);
+    });
+
+    test('Sidebar - Hovering a reference resolves a tooltip with the lines around it', async () => {
+        const testFile = path.join(__dirname, '../../../test/forwarding_store.go');
+        const document = await vscode.workspace.openTextDocument(testFile);
+        const line = document.getText().split('\n').findIndex(text => text.includes('next ForwardingStore'));
+        const location = new vscode.Location(document.uri, new vscode.Position(line, 1));
+        const item = new ReferenceTreeItem('next ForwardingStore', vscode.TreeItemCollapsibleState.None, 'reference', 'reference', location, 'next ForwardingStore');
+        item.previewHeading = 'Field · Line ' + (line + 1);
+        
+        // VS Code only resolves properties that are still undefined
+        assert.strictEqual(item.tooltip, undefined);
+        const resolved = await new GoReferenceSidebarProvider(analyzer).resolveTreeItem(item, item);
+        assert.ok(resolved.tooltip instanceof vscode.MarkdownString);
+        const tooltip = (resolved.tooltip as vscode.MarkdownString).value;
+        assert.ok(tooltip.includes('Field · Line ' + (line + 1)));
+        assert.ok(tooltip.includes('type logStore struct {'));
+        assert.ok(tooltip.includes('next ForwardingStore'));
+        assert.ok(tooltip.includes('func (l *logStore) Get'));
+    });
+
+    test('Classifier - References are classified from the source around them', async () => {
+        const document = await vscode.workspace.openTextDocument({ language: 'go', content: [
+            'package store',
+            '',
+            'type Wrapper struct {',
+            '\tStore',
+            '\tbackup Store `json:"backup"`',
+            '}',
+            '',
+            'func use(s Store, v any) Store {',
+            '\ts.Get("a")',
+            '\tw := Wrapper{backup: s}',
+            '\tw.backup = s',
+            '\tcount++',
+            '\t_ = v.(Store)',
+            '\tswitch v.(type) {',
+            '\tcase Store:',
+            '\t}',
+            '\tid := ID(42)',
+            '\t// s.Get("b")',
+            '\treturn w.backup',
+            '}',
+            ''
+        ].join('\n') });
+        const usage = (line: number, name: string, targetIsType = false) => {
+            const character = document.lineAt(line).text.indexOf(name);
+            return classifyReference(document, new vscode.Range(line, character, line, character + name.length), targetIsType);
+        };
+        
+        assert.strictEqual(usage(8, 'Get'), 'call');
+        assert.strictEqual(usage(16, 'ID', true), 'conversion');
+        assert.strictEqual(usage(10, 'backup'), 'assignment');
+        assert.strictEqual(usage(11, 'count'), 'assignment');
+        assert.strictEqual(usage(9, 'Wrapper', true), 'compositeLiteral');
+        assert.strictEqual(usage(7, 'Store', true), 'signature');
+        assert.strictEqual(usage(3, 'Store', true), 'embedding');
+        assert.strictEqual(usage(4, 'Store', true), 'fieldDeclaration');
+        assert.strictEqual(usage(12, 'Store', true), 'typeAssertion');
+        assert.strictEqual(usage(14, 'Store', true), 'typeSwitchCase');
+        assert.strictEqual(usage(17, 'Get'), 'other');
+        assert.strictEqual(usage(18, 'backup'), 'other');
+    });
+
+    test('Export - CSV fields with commas and quotes are escaped', () => {
+        const csv = formatReferences([{
+            symbol: 'Writer.Write',
+            kind: 'Call',
+            package: 'go-implementation-lens/test',
+            file: 'test/test_cases.go',
+            line: 12,
+            function: 'func main',
+            preview: 'w.Write([]byte("a,b"))'
+        }], 'csv', 'References to Writer.Write');
+        
+        const lines = csv.trim().split('\r\n');
+        assert.strictEqual(lines[0], 'symbol,kind,package,file,line,function,preview');
+        assert.strictEqual(lines[1], 'Writer.Write,Call,go-implementation-lens/test,test/test_cases.go,12,func main,"w.Write([]byte(""a,b""))"');
+    });
+
+    test('Export - Markdown code spans are fenced longer than the backtick runs inside', () => {
+        const row = { symbol: 'Query.Run', kind: 'Call', package: 'store', file: 'store/query.go', line: 3, function: 'func load' };
+        const markdown = (preview: string) => formatReferences([{ ...row, preview }], 'markdown', 'References to Query.Run').split('\n')
+            .find(line => line.startsWith('- [ ]'));
+        
+        assert.ok(markdown('q.Run(ctx)')!.endsWith(': `q.Run(ctx)`'));
+        assert.ok(markdown('q.Run(`a`)')!.endsWith(': `` q.Run(`a`) ``'));
+        assert.ok(markdown('q.Run(`a``b`)')!.endsWith(': ``` q.Run(`a``b`) ```'));
+    });
+
+    test('CodeLens - Title templates expand counts and plural suffixes', () => {
+        const config = vscode.workspace.getConfiguration('goImplementationLens');
+        
+        assert.strictEqual(formatLensTitle(config, 'implementations', { count: 1 }), '1 implementation');
+        assert.strictEqual(formatLensTitle(config, 'implementations', { count: 3 }), '3 implementations');
+        assert.strictEqual(formatLensTitle(config, 'implementations', { count: 7, production: 2, test: 5, mocks: 0 }), '2 implementations (+5 test)');
+        assert.strictEqual(formatLensTitle(config, 'implementations', { count: 3, production: 0, test: 1, mocks: 2 }), '0 implementations (+1 test, +2 mocks)');
+        assert.strictEqual(formatLensTitle(config, 'combined', { implementations: 3, references: 1 }), '3 impls · 1 ref');
+    });
+
+    test('Health - A type only named in its receivers and an assertion is a dead implementation', async () => {
+        const testFile = path.join(__dirname, '../../../test/dead_implementation.go');
+        const document = await vscode.workspace.openTextDocument(testFile);
+        
+        const issues = await findDeadImplementations(document, await analyzer.analyzeDocument(document));
+        
+        const deadStore = issues.find(issue => issue.memberName === 'DeadStore');
+        assert.ok(deadStore, 'DeadStore should be reported as a dead implementation');
+        assert.strictEqual(deadStore.interfaceName, 'DeadStoreInterface');
+    });
+
+    test('Health - Interface methods only called via concrete types or not at all are unused', async () => {
+        const document = await vscode.workspace.openTextDocument({ language: 'go', content: [
+            'package store',
+            '',
+            'type Store interface {',
+            '\tGet(id string) string',
+            '\tPut(id string)',
+            '\tClose()',
+            '}',
+            '',
+            'func use(s Store, m *memStore) {',
+            '\ts.Get("a")',
+            '\tm.Put("b")',
+            '}',
+            ''
+        ].join('\n') });
+        // Calls through the interface resolve to the interface method, calls on memStore elsewhere
+        const definitions = vscode.languages.registerDefinitionProvider({ language: 'go' }, {
+            provideDefinition: (_document, position) => new vscode.Location(document.uri, new vscode.Position(position.line === 9 ? 3 : 12, 1))
+        });
+        const method = (name: string, line: number, references: vscode.Location[]): MethodInfo => ({
+            name: name,
+            range: new vscode.Range(line, 1, line, document.lineAt(line).text.length),
+            implementations: [],
+            implementationsByCategory: { production: [], test: [], mock: [] },
+            references: references
+        });
+        try {
+            const issues = await findUnusedInterfaceMethods(document, {
+                interfaces: [{
+                    name: 'Store',
+                    range: new vscode.Range(2, 0, 6, 1),
+                    uri: document.uri,
+                    implementations: [],
+                    implementationsByCategory: { production: [], test: [], mock: [] },
+                    references: [],
+                    methods: [
+                        method('Get', 3, [new vscode.Location(document.uri, new vscode.Position(9, 3))]),
+                        method('Put', 4, [new vscode.Location(document.uri, new vscode.Position(10, 3))]),
+                        method('Close', 5, [])
+                    ]
+                }],
+                types: [],
+                methodImplementations: [],
+                symbolReferences: []
+            });
+            assert.deepStrictEqual(issues.map(issue => issue.message), [
+                'Interface method Store.Put is never called through the interface (1 use via a concrete type)',
+                'Interface method Store.Close is never used'
+            ]);
+            assert.deepStrictEqual(issues[1].range, new vscode.Range(5, 1, 5, 6));
+        } finally {
+            definitions.dispose();
+        }
+    });
+
+    test('Segregation - Methods used by the same consumers are clustered together', () => {
+        const location = new vscode.Location(vscode.Uri.file('/tmp/consumers.go'), new vscode.Position(0, 0));
+        const consumer = (name: string, methods: string[]) => ({ name, location, methods: new Set(methods) });
+        
+        const clusters = clusterMethods(['GetList', 'GetByID', 'Search', 'Delete'], [
+            consumer('func listItems', ['GetList', 'GetByID']),
+            consumer('func showItem', ['GetByID', 'GetList']),
+            consumer('func searchItems', ['Search'])
+        ]);
+        
+        assert.deepStrictEqual(clusters, [['GetList', 'GetByID'], ['Search']]);
+    });
+
+    test('Segregation - Multi-line signatures are joined without comments', async () => {
+        const document = await vscode.workspace.openTextDocument({ language: 'go', content:
+            'type Store interface {\n\tGet(\n\t\tid string, // the ID\n\t\tversion int,\n\t) (Item, error) // latest version\n}\n' });
+        const signature = getMethodSignature(document, {
+            name: 'Get',
+            range: new vscode.Range(1, 1, 4, 15),
+            implementations: [],
+            implementationsByCategory: { production: [], test: [], mock: [] },
+            references: []
+        });
+        assert.strictEqual(signature, 'Get(id string, version int) (Item, error)');
+    });
+
+    test('Mocks - Method signatures with grouped, variadic and unnamed parameters are parsed', () => {
+        assert.deepStrictEqual(parseMethodSignature('Search(query string, opts ...SearchOption) ([]*Item, error)'), {
+            name: 'Search',
+            params: [{ name: 'query', type: 'string' }, { name: 'opts', type: '...SearchOption' }],
+            results: ['[]*Item', 'error']
+        });
+        assert.deepStrictEqual(parseMethodSignature('Pair(a, b int) (n int, err error) // comment'), {
+            name: 'Pair',
+            params: [{ name: 'a', type: 'int' }, { name: 'b', type: 'int' }],
+            results: ['int', 'error']
+        });
+        assert.deepStrictEqual(parseMethodSignature('Watch(chan int, func(int) error)'), {
+            name: 'Watch',
+            params: [{ name: '', type: 'chan int' }, { name: '', type: 'func(int) error' }],
+            results: []
+        });
+    });
+
+    test('Rename - New interface method names must be free, non-blank Go identifiers', () => {
+        const range = new vscode.Range(0, 0, 0, 0);
+        const method = (name: string) => ({ name, range, implementations: [], implementationsByCategory: { production: [], test: [], mock: [] }, references: [] });
+        const get = method('Get');
+        const store = { ...method('Store'), uri: vscode.Uri.file('/tmp/store.go'), methods: [get, method('List')] };
+        
+        assert.strictEqual(validateMethodName('Fetch', store, get), undefined);
+        assert.strictEqual(validateMethodName('Get', store, get), undefined);
+        assert.ok(validateMethodName('List', store, get));
+        assert.ok(validateMethodName('2Get', store, get));
+        assert.ok(validateMethodName('func', store, get));
+        assert.ok(validateMethodName('_', store, get));
+    });
+
+    test('Rename - Calls on the line of a one-line forwarding implementation are renamed too', async () => {
+        const testFile = path.join(__dirname, '../../../test/forwarding_store.go');
+        const document = await vscode.workspace.openTextDocument(testFile);
+        const lineOf = (text: string) => document.getText().split('\n').findIndex(line => line.includes(text));
+        
+        // Locations as gopls reports them, pointing at the identifiers
+        const methodLine = lineOf('\tGet(id string)');
+        const implementationLine = lineOf('func (l *logStore) Get');
+        const implementationText = document.lineAt(implementationLine).text;
+        const implementation = new vscode.Location(document.uri, new vscode.Position(implementationLine, implementationText.indexOf('Get')));
+        const forwardedCall = new vscode.Location(document.uri, new vscode.Position(implementationLine, implementationText.lastIndexOf('Get')));
+        const method: MethodInfo = {
+            name: 'Get',
+            range: new vscode.Range(methodLine, 1, methodLine, document.lineAt(methodLine).text.length),
+            implementations: [implementation],
+            implementationsByCategory: { production: [implementation], test: [], mock: [] },
+            references: [forwardedCall]
+        };
+        const goAnalyzer = {
+            getReferences: async () => [implementation, forwardedCall],
+            getImplementations: async () => [implementation],
+            findInterfaceAt: async () => ({}),
+            getInterfaceAndMethodName: async () => undefined
+        } as unknown as GoAnalyzer;
+        
+        const targets = await findMethodTargets(goAnalyzer, document, method, new vscode.CancellationTokenSource().token);
+        assert.ok(targets);
+        assert.deepStrictEqual(targets.callSites.map(callSite => callSite.range.start), [forwardedCall.range.start]);
+        
+        const edit = await buildInterfaceMethodRename(document, method, targets, 'Fetch');
+        const renamed = edit.get(document.uri).map(textEdit => `${textEdit.range.start.line}:${textEdit.range.start.character}`);
+        assert.deepStrictEqual(renamed, [
+            `${methodLine}:1`,
+            `${implementationLine}:${implementation.range.start.character}`,
+            `${implementationLine}:${forwardedCall.range.start.character}`
+        ]);
+    });
+
+    test('Change Signature - New parameters are parsed and get configured or zero value arguments', () => {
+        assert.deepStrictEqual(parseNewParameter(' ctx context.Context '), { name: 'ctx', type: 'context.Context' });
+        assert.strictEqual(parseNewParameter('context.Context'), undefined);
+        assert.strictEqual(parseNewParameter('opts ...Option'), undefined);
+        
+        const configured = { 'context.Context': 'context.TODO()' };
+        assert.strictEqual(getDefaultArgument('context.Context', configured), 'context.TODO()');
+        assert.strictEqual(getDefaultArgument('*Options', configured), 'nil');
+        assert.strictEqual(getDefaultArgument('map[string]int', configured), 'nil');
+        assert.strictEqual(getDefaultArgument('string', configured), '""');
+        assert.strictEqual(getDefaultArgument('int64', configured), '0');
+        assert.strictEqual(getDefaultArgument('time.Duration', configured), '');
+    });
+
+    test('Change Signature - Parameters are added to multi-line, unnamed and taken lists, and method values are skipped', async () => {
+        const document = await vscode.workspace.openTextDocument({ language: 'go', content: [
+            'package store',
+            '',
+            'type Store interface {',
+            '\tGet(',
+            '\t\tid string,',
+            '\t\tversion int,',
+            '\t) (Item, error)',
+            '\tPut(Item) error',
+            '}',
+            '',
+            'func (m *memStore) Get(ctx string, id string, version int) (Item, error) { return Item{}, nil }',
+            '',
+            'func (m *memStore) Put(Item) error { return nil }',
+            '',
+            'func use(s Store) {',
+            '\ts.Get("a", 1)',
+            '\tget := s.Get',
+            '\ts.Put(Item{})',
+            '}',
+            ''
+        ].join('\n') });
+        const at = (text: string, name: string) => {
+            const line = document.getText().split('\n').findIndex(candidate => candidate.includes(text));
+            return new vscode.Location(document.uri, new vscode.Position(line, document.lineAt(line).text.indexOf(text) + text.indexOf(name)));
+        };
+        const methodInfo = (name: string, start: vscode.Location, end: vscode.Location): MethodInfo => ({
+            name: name,
+            range: new vscode.Range(start.range.start, document.lineAt(end.range.start.line).range.end),
+            implementations: [],
+            implementationsByCategory: { production: [], test: [], mock: [] },
+            references: []
+        });
+        const goAnalyzer = {} as GoAnalyzer;
+        
+        // Last into a multi-line list with a trailing comma, "_" where the name is taken, and calls with the argument
+        const get = methodInfo('Get', at('\tGet(', 'Get'), at(') (Item, error)', ')'));
+        const methodValue = at('get := s.Get', 'Get');
+        const getChange = await buildSignatureChange(goAnalyzer, document, get, {
+            implementations: [at('memStore) Get', 'Get')],
+            externalImplementations: [],
+            otherInterfaceMethods: [],
+            callSites: [at('s.Get("a"', 'Get'), methodValue]
+        }, { name: 'ctx', type: 'context.Context', position: 'last', defaultArgument: 'context.TODO()' });
+        const changed = applyTextEdits(document, getChange.edit);
+        assert.ok(changed.includes('package store\n\nimport "context"\n'));
+        assert.ok(changed.includes('\t\tversion int, ctx context.Context,\n\t) (Item, error)'));
+        assert.ok(changed.includes('Get(ctx string, id string, version int, _ context.Context) (Item, error)'));
+        assert.ok(changed.includes('\ts.Get("a", 1, context.TODO())'));
+        assert.ok(changed.includes('\tget := s.Get\n'));
+        assert.deepStrictEqual(getChange.skippedReferences, [methodValue]);
+        
+        // First into unnamed lists, which stay unnamed
+        const put = methodInfo('Put', at('\tPut(', 'Put'), at('\tPut(', 'Put'));
+        const putChange = await buildSignatureChange(goAnalyzer, document, put, {
+            implementations: [at('memStore) Put', 'Put')],
+            externalImplementations: [],
+            otherInterfaceMethods: [],
+            callSites: [at('s.Put(', 'Put')]
+        }, { name: 'retries', type: 'int', position: 'first', defaultArgument: '0' });
+        const putChanged = applyTextEdits(document, putChange.edit);
+        assert.ok(putChanged.includes('\tPut(int, Item) error'));
+        assert.ok(putChanged.includes('func (m *memStore) Put(int, Item) error'));
+        assert.ok(putChanged.includes('\ts.Put(0, Item{})'));
+        assert.ok(!putChanged.includes('import'));
+    });
+
+    test('Change Signature - Edits still waiting for the debounced refresh are analyzed before refactoring', async () => {
+        // A file inside the workspace, since implementations outside it are refused
+        const file = vscode.Uri.file(path.join(__dirname, 'stale_store.go'));
+        await vscode.workspace.fs.writeFile(file, Buffer.from([
+            'package store',
+            '',
+            'type Store interface {',
+            '\tGet(id string) string',
+            '}',
+            '',
+            'func (m *memStore) Get(id string) string { return "" }',
+            '',
+            'func use(s Store) string {',
+            '\treturn s.Get("a")',
+            '}',
+            ''
+        ].join('\n')));
+        
+        // gopls stand-ins answering from the current text
+        const nameAt = (document: vscode.TextDocument, text: string, name: string) => {
+            const line = document.getText().split('\n').findIndex(candidate => candidate.includes(text));
+            const character = document.lineAt(line).text.indexOf(text) + text.indexOf(name);
+            return new vscode.Range(line, character, line, character + name.length);
+        };
+        const selector = { language: 'go', pattern: file.fsPath };
+        const providers = [
+            vscode.languages.registerDocumentSymbolProvider(selector, {
+                provideDocumentSymbols: document => {
+                    const name = nameAt(document, 'type Store', 'Store');
+                    const end = document.getText().split('\n').findIndex(line => line === '}');
+                    const store = new vscode.DocumentSymbol('Store', '', vscode.SymbolKind.Interface, new vscode.Range(name.start.line, 0, end, 1), name);
+                    const get = nameAt(document, '\tGet(', 'Get');
+                    store.children = [new vscode.DocumentSymbol('Get', '', vscode.SymbolKind.Method, document.lineAt(get.start.line).range.with(get.start), get)];
+                    return [store];
+                }
+            }),
+            vscode.languages.registerImplementationProvider(selector, {
+                provideImplementation: (document, position) =>
+                    nameAt(document, 'type Store', 'Store').contains(position) || nameAt(document, '\tGet(', 'Get').contains(position)
+                        ? [new vscode.Location(document.uri, nameAt(document, 'memStore) Get', 'Get'))]
+                        : []
+            }),
+            vscode.languages.registerReferenceProvider(selector, {
+                provideReferences: (document, position) => nameAt(document, '\tGet(', 'Get').contains(position)
+                    ? [new vscode.Location(document.uri, nameAt(document, '\tGet(', 'Get')), new vscode.Location(document.uri, nameAt(document, 's.Get(', 'Get'))]
+                    : []
+            })
+        ];
+        
+        try {
+            const document = await vscode.workspace.openTextDocument(file);
+            const goAnalyzer = new GoAnalyzer();
+            assert.strictEqual((await goAnalyzer.analyzeDocument(document)).interfaces.length, 1);
+            
+            // Typing marks the file changed, and its previous analysis is served until the refresh
+            const editor = await vscode.window.showTextDocument(document);
+            await editor.edit(builder => builder.insert(new vscode.Position(1, 0), '\n// Store keeps items.\n'));
+            goAnalyzer.markChanged(document.uri.fsPath);
+            
+            const target = await findInterfaceMethodAt(goAnalyzer, document, nameAt(document, '\tGet(', 'Get').start);
+            assert.ok(target, 'The method should be found at its current position');
+            const targets = await findMethodTargets(goAnalyzer, target.document, target.method, new vscode.CancellationTokenSource().token);
+            assert.ok(targets);
+            const change = await buildSignatureChange(goAnalyzer, target.document, target.method, targets, {
+                name: 'ctx', type: 'int', position: 'first', defaultArgument: '0'
+            });
+            const changed = applyTextEdits(document, change.edit);
+            assert.ok(changed.includes('\tGet(ctx int, id string) string'));
+            assert.ok(changed.includes('func (m *memStore) Get(ctx int, id string) string'));
+            assert.ok(changed.includes('\treturn s.Get(0, "a")'));
+            
+            await vscode.commands.executeCommand('workbench.action.revertAndCloseActiveEditor');
+        } finally {
+            providers.forEach(provider => provider.dispose());
+            await vscode.workspace.fs.delete(file);
+        }
+    });
+
+    test('Change Signature - Types are qualified in other packages, and unknown packages are refused', async () => {
+        const document = await vscode.workspace.openTextDocument({ language: 'go', content: 'package store\n\ntype Store interface {\n\tPut(item Item) error\n}\n' });
+        const external = await vscode.workspace.openTextDocument({ language: 'go', content: [
+            'package store_test',
+            '',
+            'import "testing"',
+            '',
+            'func (f *fakeStore) Put(item store.Item) error { return nil }',
+            '',
+            'func TestPut(t *testing.T) { _ = s.Put(store.Item{}) }',
+            ''
+        ].join('\n') });
+        const put: MethodInfo = {
+            name: 'Put',
+            range: new vscode.Range(3, 1, 3, 21),
+            implementations: [],
+            implementationsByCategory: { production: [], test: [], mock: [] },
+            references: []
+        };
+        const targets = {
+            implementations: [new vscode.Location(external.uri, new vscode.Position(4, 20))],
+            externalImplementations: [],
+            otherInterfaceMethods: [],
+            callSites: [new vscode.Location(external.uri, new vscode.Position(6, 35))]
+        };
+        const goAnalyzer = { getPackagePath: async () => 'example.com/store' } as unknown as GoAnalyzer;
+        
+        const change = await buildSignatureChange(goAnalyzer, document, put, targets, {
+            name: 'opts', type: '*Options', position: 'first', defaultArgument: 'DefaultOptions()'
+        });
+        assert.ok(applyTextEdits(document, change.edit).includes('\tPut(opts *Options, item Item) error'));
+        const changed = applyTextEdits(external, change.edit);
+        assert.ok(changed.includes('package store_test\n\nimport "example.com/store"\n'));
+        assert.ok(changed.includes('Put(opts *store.Options, item store.Item) error'));
+        assert.ok(changed.includes('s.Put(store.DefaultOptions(), store.Item{})'));
+        
+        await assert.rejects(buildSignatureChange(goAnalyzer, document, put, targets, {
+            name: 'cfg', type: 'config.Config', position: 'last'
+        }), /config/);
+    });
 });
 
+// Text of the document with the edit's changes to it applied
+function applyTextEdits(document: vscode.TextDocument, edit: vscode.WorkspaceEdit): string {
+    let text = document.getText();
+    const edits = [...edit.get(document.uri)].sort((a, b) => document.offsetAt(b.range.start) - document.offsetAt(a.range.start));
+    for (const textEdit of edits) {
+        text = text.substring(0, document.offsetAt(textEdit.range.start)) + textEdit.newText + text.substring(document.offsetAt(textEdit.range.end));
+    }
+    return text;
+}
+
 // Helper function to log test results
 function logTestResult(testName: string, passed: boolean, details: string) {
     const status = passed ? '✅ PASS' : '❌ FAIL';
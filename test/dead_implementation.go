@@ -0,0 +1,18 @@
+package main
+
+// Test Case 14: Dead Implementation
+// EXPECTED: DeadStore is reported as a dead implementation: its only references are its method
+// receivers and a compile-time assertion, and DeadStoreInterface is never used either
+type DeadStoreInterface interface {
+	Load(key string) (string, error)
+}
+
+type DeadStore struct {
+	data map[string]string
+}
+
+var _ DeadStoreInterface = (*DeadStore)(nil)
+
+func (s *DeadStore) Load(key string) (string, error) {
+	return s.data[key], nil
+}
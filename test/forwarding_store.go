@@ -0,0 +1,14 @@
+package main
+
+// Test Case 15: Forwarding Implementation
+// EXPECTED: Refactoring ForwardingStore.Get edits the declaration of logStore.Get and the
+// l.next.Get(id) call on the same line
+type ForwardingStore interface {
+	Get(id string) (string, error)
+}
+
+type logStore struct {
+	next ForwardingStore
+}
+
+func (l *logStore) Get(id string) (string, error) { return l.next.Get(id) }